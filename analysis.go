@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Finding severities.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Finding represents a recommendation produced by an analysis rule.
+type Finding struct {
+	RuleID     string  `json:"ruleId"`
+	Severity   string  `json:"severity"`
+	Message    string  `json:"message"`
+	StepIDs    []int   `json:"stepIds,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Rule represents an analysis rule. Rule IDs are stable and may be relied upon
+// by downstream automation.
+type Rule struct {
+	ID          string
+	Description string
+	Check       func(t Timeline) []Finding
+}
+
+// rules are the registered analysis rules.
+var rules = []Rule{
+	{
+		ID:          "startup-failure",
+		Description: "Steps tagged with an exception, or that never ended.",
+		Check:       checkStartupFailure,
+	},
+	{
+		ID:          "slow-step",
+		Description: "Top-level steps taking more than 5s.",
+		Check:       checkSlowSteps,
+	},
+	{
+		ID:          "lazy-init-candidate",
+		Description: "Beans taking at least 200ms to instantiate.",
+		Check:       checkLazyInitCandidates,
+	},
+	{
+		ID:          "autoconfig-exclude-candidate",
+		Description: "Auto-configurations taking at least 50ms to initialize.",
+		Check:       checkAutoConfigExcludeCandidates,
+	},
+	{
+		ID:          "malformed-hierarchy",
+		Description: "Steps with unknown or cyclic parent ids.",
+		Check:       checkMalformedHierarchy,
+	},
+	{
+		ID:          "timeline-integrity",
+		Description: "Negative durations, steps outside the timeline or their parent, and clock skew artifacts.",
+		Check:       checkIntegrity,
+	},
+	{
+		ID:          "jmx-enabled",
+		Description: "JMX infrastructure initialized during startup.",
+		Check:       checkJMXEnabled,
+	},
+	{
+		ID:          "db-migration",
+		Description: "Database migrations run by Flyway or Liquibase during startup.",
+		Check:       checkMigrations,
+	},
+	{
+		ID:          "jpa-init",
+		Description: "Entity manager factories and Spring Data repositories taking at least 200ms to initialize.",
+		Check:       checkJPAInitialization,
+	},
+	{
+		ID:          "web-server-init",
+		Description: "Embedded Tomcat, Jetty, Netty or Undertow initialization, flagging steps taking at least 500ms.",
+		Check:       checkWebServer,
+	},
+	{
+		ID:          "component-scan",
+		Description: "Classpath and component scanning, flagged when taking at least 20% of startup.",
+		Check:       checkComponentScan,
+	},
+	{
+		ID:          "datasource-init",
+		Description: "Datasource and connection pool warm-up, flagging pools blocking startup for at least 1s.",
+		Check:       checkDataSourceInit,
+	},
+	{
+		ID:          "sql-init-on-boot",
+		Description: "spring.sql.init schema and data scripts run during startup.",
+		Check:       checkSQLInit,
+	},
+	{
+		ID:          "runners",
+		Description: "ApplicationRunner and CommandLineRunner execution taking at least 100ms before readiness.",
+		Check:       checkRunners,
+	},
+	{
+		ID:          "bean-availability",
+		Description: "When the first datasource, web server and cache client beans became available.",
+		Check:       checkBeanAvailability,
+	},
+}
+
+func checkSlowSteps(t Timeline) []Finding {
+	var findings []Finding
+	for _, e := range t.RootEvents() {
+		if e.Duration() > 5*time.Second {
+			findings = append(findings, Finding{
+				Severity:   SeverityCritical,
+				Message:    fmt.Sprintf("%s took %s.", e.StartupStep.Name, formatDuration(e.Duration())),
+				StepIDs:    []int{e.StartupStep.ID},
+				DurationMs: millis(e.Duration()),
+			})
+		}
+	}
+	return findings
+}
+
+func checkLazyInitCandidates(t Timeline) []Finding {
+	var findings []Finding
+	for _, b := range slowBeans(t, 200*time.Millisecond) {
+		findings = append(findings, Finding{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("bean %s took %s to instantiate, excluding its dependencies; consider making it lazy.", b.Name, formatDuration(b.SelfTime)),
+			StepIDs:    []int{b.StepID},
+			DurationMs: millis(b.SelfTime),
+		})
+	}
+	return findings
+}
+
+func checkAutoConfigExcludeCandidates(t Timeline) []Finding {
+	var findings []Finding
+	for _, c := range autoConfigCosts(t, 50*time.Millisecond) {
+		findings = append(findings, Finding{
+			Severity:   SeverityInfo,
+			Message:    fmt.Sprintf("%s took %s to initialize; exclude it if unused.", c.Class, formatDuration(c.Duration)),
+			DurationMs: millis(c.Duration),
+		})
+	}
+	return findings
+}
+
+func checkMalformedHierarchy(t Timeline) []Finding {
+	tree := buildTree(t)
+	var findings []Finding
+	if len(tree.Orphans) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%d steps reference a parent that does not exist; they are shown as top-level steps.", len(tree.Orphans)),
+			StepIDs:  tree.Orphans,
+		})
+	}
+	if len(tree.Cycles) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("%d cyclic parent chains were found; they were broken at these steps, shown as top-level steps.", len(tree.Cycles)),
+			StepIDs:  tree.Cycles,
+		})
+	}
+	return findings
+}
+
+func checkJMXEnabled(t Timeline) []Finding {
+	if !usesJMX(t) {
+		return nil
+	}
+	return []Finding{{
+		Severity: SeverityInfo,
+		Message:  "jmx is enabled; set spring.jmx.enabled=false if nothing reads the mbeans.",
+	}}
+}
+
+// AnalysisStep represents a step referenced by the analysis.
+type AnalysisStep struct {
+	ID         int     `json:"id"`
+	Name       string  `json:"name"`
+	Key        string  `json:"key"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Phase represents a top-level startup phase.
+type Phase struct {
+	AnalysisStep
+	Percent float64 `json:"percent"`
+}
+
+// AnalysisMilestones represents the time to the key startup milestones; unknown
+// ones are omitted.
+type AnalysisMilestones struct {
+	ContextRefreshedMs float64 `json:"contextRefreshedMs,omitempty"`
+	StartedMs          float64 `json:"startedMs,omitempty"`
+	ReadyMs            float64 `json:"readyMs,omitempty"`
+}
+
+// Analysis represents the output of all analyzers for a report.
+type Analysis struct {
+	SpringBootVersion string             `json:"springBootVersion"`
+	StartupTimeMs     float64            `json:"startupTimeMs"`
+	Milestones        AnalysisMilestones `json:"milestones"`
+	Score             int                `json:"score"`
+	CriticalPath      []AnalysisStep     `json:"criticalPath"`
+	Phases            []Phase            `json:"phases"`
+	Findings          []Finding          `json:"findings"`
+	Truncation        *Truncation        `json:"truncation,omitempty"`
+}
+
+// analyze runs all analyzers over the report, within the processing timeout.
+func analyze(ctx context.Context, report *StartupReport) (Analysis, error) {
+	return withProcessingTimeout(ctx, "analyzing the report", func(ctx context.Context) (Analysis, error) {
+		return runAnalyzers(ctx, report)
+	})
+}
+
+// runAnalyzers runs all analyzers over the report, stopping between rules
+// once ctx is done.
+func runAnalyzers(ctx context.Context, report *StartupReport) (Analysis, error) {
+	t := report.Timeline
+	a := Analysis{
+		SpringBootVersion: report.SpringBootVersion,
+		StartupTimeMs:     millis(t.Duration()),
+		Truncation:        report.Truncation,
+		CriticalPath:      []AnalysisStep{},
+		Findings:          []Finding{},
+	}
+
+	// milestones.
+	a.Milestones = analysisMilestones(t.Milestones())
+
+	// critical path.
+	for _, e := range criticalPath(t) {
+		a.CriticalPath = append(a.CriticalPath, analysisStep(e))
+	}
+
+	// phases.
+	a.Phases = phases(t)
+
+	// findings.
+	for _, r := range rules {
+		if err := ctx.Err(); err != nil {
+			return Analysis{}, err
+		}
+		for _, f := range r.Check(t) {
+			f.RuleID = r.ID
+			a.Findings = append(a.Findings, f)
+		}
+	}
+
+	a.Score = score(t.Duration(), a.Findings)
+	return a, nil
+}
+
+// analysisMilestones converts the milestones to milliseconds.
+func analysisMilestones(m Milestones) AnalysisMilestones {
+	return AnalysisMilestones{
+		ContextRefreshedMs: millis(m.ContextRefreshed),
+		StartedMs:          millis(m.Started),
+		ReadyMs:            millis(m.Ready),
+	}
+}
+
+// phases returns the top-level steps with their share of the startup time.
+func phases(t Timeline) []Phase {
+	phases := []Phase{}
+	for _, e := range t.RootEvents() {
+		p := Phase{AnalysisStep: analysisStep(e)}
+		if t.Duration() > 0 {
+			p.Percent = 100 * float64(e.Duration()) / float64(t.Duration())
+		}
+		phases = append(phases, p)
+	}
+	return phases
+}
+
+// criticalPath returns the chain of steps starting at the slowest top-level
+// step and descending into the slowest child at each level.
+func criticalPath(t Timeline) []Events {
+	var path []Events
+	for n := slowestNode(buildTree(t).Roots); n != nil; n = slowestNode(n.Children) {
+		path = append(path, n.Event)
+	}
+	return path
+}
+
+// score rates the startup from 0 to 100. It loses 5 points per second of
+// startup time above the first second, and points for each finding by severity.
+func score(total time.Duration, findings []Finding) int {
+	s := 100.0
+	if total > time.Second {
+		s -= 5 * (total - time.Second).Seconds()
+	}
+	penalties := map[string]float64{SeverityInfo: 1, SeverityWarning: 3, SeverityCritical: 10}
+	for _, f := range findings {
+		s -= penalties[f.Severity]
+	}
+	if s < 0 {
+		return 0
+	}
+	return int(s)
+}
+
+func analysisStep(e Events) AnalysisStep {
+	return AnalysisStep{
+		ID:         e.StartupStep.ID,
+		Name:       e.StartupStep.Name,
+		Key:        e.StartupStep.Key(),
+		DurationMs: millis(e.Duration()),
+	}
+}
+
+// millis converts a duration to fractional milliseconds, rounded to the
+// microsecond so the same duration is always formatted the same way.
+func millis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// sortedRules returns the registered rules ordered by ID.
+func sortedRules() []Rule {
+	sorted := append([]Rule(nil), rules...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+func handleAnalysis(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// analyze report.
+	a, err := analyze(r.Context(), report.StartupReport)
+	if err != nil {
+		log.Printf("failed to analyze report: %s", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// write analysis.
+	setWarningHeaders(w, report)
+	writeJSON(w, a)
+}
+
+func handleRules(w http.ResponseWriter, r *http.Request) {
+	type rule struct {
+		ID          string `json:"id"`
+		Description string `json:"description"`
+	}
+	var list []rule
+	for _, r := range sortedRules() {
+		list = append(list, rule{ID: r.ID, Description: r.Description})
+	}
+	writeJSON(w, list)
+}
+
+// writeJSON writes v as an indented json response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := encodeJSON(w, v); err != nil {
+		log.Printf("failed to write json: %s", err)
+	}
+}
+
+// encodeJSON writes v in goat's canonical json form, shared by all json output
+// so exports and baselines diff cleanly: two space indentation, unescaped html
+// characters and a trailing newline. Field order follows the struct
+// declarations and arrays are sorted where they're built.
+func encodeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runAnalyze implements the analyze command: it prints the report analysis to
+// the terminal, optionally re-fetching it and printing what changed.
+func runAnalyze(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	path := fs.String("report", "", "spring actuator startup report, or - for stdin.")
+	url := fs.String("url", "", "actuator startup endpoint, e.g. http://localhost:8080/actuator/startup.")
+	limit := fs.Int("limit", 10, "number of slowest steps printed.")
+	watch := fs.Bool("watch", false, "re-fetch the report on an interval and print only what changed.")
+	interval := fs.Duration("interval", 5*time.Second, "watch interval.")
+	threshold := fs.Duration("threshold", 100*time.Millisecond, "minimum step delta printed in watch mode.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerAnalysisFlags(fs)
+	registerColorFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if (*path == "") == (*url == "") {
+		log.Fatal("exactly one of report or url is required!")
+	}
+	load := func() (*StartupReport, error) {
+		if *url != "" {
+			return fetchReport(context.Background(), *url)
+		}
+		return loadReport(context.Background(), *path)
+	}
+
+	// print analysis.
+	report, err := load()
+	if err != nil {
+		log.Fatalf("failed to load report: %s", err)
+	}
+	a, err := analyze(context.Background(), report)
+	if err != nil {
+		log.Fatalf("failed to analyze report: %s", err)
+	}
+	printAnalysis(os.Stdout, report, a, *limit)
+	if !*watch {
+		return
+	}
+
+	// watch changes.
+	for range time.Tick(*interval) {
+		next, err := load()
+		if err != nil {
+			log.Printf("failed to load report: %s", err)
+			continue
+		}
+		printChanges(os.Stdout, compareReports(report, next), *threshold)
+		report = next
+	}
+}
+
+// printAnalysis prints the startup time, the slowest steps and the findings
+// of the analysis.
+func printAnalysis(w io.Writer, report *StartupReport, a Analysis, limit int) {
+	fmt.Fprintf(w, "STARTUP TIME: %s\n", colorByDuration(formatDuration(report.Timeline.Duration()), report.Timeline.Duration()))
+	if m := formatMilestones(report.Timeline.Milestones()); m != "" {
+		fmt.Fprintf(w, "MILESTONES: %s\n", m)
+	}
+	fmt.Fprintln(w)
+	if t := report.Truncation; t != nil {
+		fmt.Fprintf(w, "TRUNCATED: showing the top-level and slowest %d of %d events.\n\n", t.Kept, t.Total)
+	}
+
+	fmt.Fprintf(w, "SLOWEST STEPS:\n")
+	for _, e := range report.Timeline.Slowest(limit) {
+		fmt.Fprintf(w, "  %s  [%d] %s\n", colorByDuration(fmt.Sprintf("%10s", formatDuration(e.Duration())), e.Duration()), e.StartupStep.ID, e.StartupStep.Key())
+	}
+
+	fmt.Fprintf(w, "\nFINDINGS (score %d):\n", a.Score)
+	for _, f := range a.Findings {
+		fmt.Fprintf(w, "  %-8s %s: %s\n", f.Severity, f.RuleID, f.Message)
+	}
+}
+
+// printChanges prints the startup time delta and the steps that changed by more
+// than threshold. Nothing is printed when nothing changed.
+func printChanges(w io.Writer, c Comparison, threshold time.Duration) {
+	var changed []StepDiff
+	for _, d := range c.Steps {
+		if d.Delta() > threshold || -d.Delta() > threshold {
+			changed = append(changed, d)
+		}
+	}
+	if len(changed) == 0 && c.TotalDelta() == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n[%s] STARTUP TIME: %s (%s)\n", time.Now().Format("15:04:05"), colorByDuration(formatDuration(c.Head.Timeline.Duration()), c.Head.Timeline.Duration()), formatDelta(c.TotalDelta(), c.Base.Timeline.Duration()))
+	for _, d := range changed {
+		switch d.Status {
+		case StepAdded:
+			fmt.Fprintf(w, "  new      %s: %s\n", d.Key, colorByDuration(formatDuration(d.Head), d.Head))
+		case StepRemoved:
+			fmt.Fprintf(w, "  removed  %s: %s\n", d.Key, formatDuration(d.Base))
+		default:
+			fmt.Fprintf(w, "  changed  %s: %s -> %s (%s)\n", d.Key, formatDuration(d.Base), colorByDuration(formatDuration(d.Head), d.Head), formatDelta(d.Delta(), d.Base))
+		}
+	}
+}
+
+// formatMilestones formats the known milestones, e.g. "context refreshed 6.5s,
+// ready 7.1s".
+func formatMilestones(m Milestones) string {
+	var parts []string
+	for _, milestone := range []struct {
+		name string
+		d    time.Duration
+	}{{"context refreshed", m.ContextRefreshed}, {"started", m.Started}, {"ready", m.Ready}} {
+		if milestone.d > 0 {
+			parts = append(parts, milestone.name+" "+formatDuration(milestone.d))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// EventDetail represents a step with its position in the hierarchy.
+type EventDetail struct {
+	AnalysisStep
+	ParentID  *int      `json:"parentId,omitempty"`
+	StartTime time.Time `json:"startTime"` // in UTC.
+	EndTime   time.Time `json:"endTime"`
+	Tags      []Tags    `json:"tags"`
+}
+
+// eventDetail returns the details of the event.
+func eventDetail(e Events) EventDetail {
+	return EventDetail{
+		AnalysisStep: analysisStep(e),
+		ParentID:     e.StartupStep.ParentID,
+		StartTime:    e.StartTime.UTC(),
+		EndTime:      e.EndTime.UTC(),
+		Tags:         append([]Tags{}, e.StartupStep.Tags...),
+	}
+}
+
+// StepDetail represents a step with its children.
+type StepDetail struct {
+	EventDetail
+	Children []AnalysisStep `json:"children"`
+}
+
+func handleStep(w http.ResponseWriter, r *http.Request) {
+	// get step id.
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid step id", http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// find step and its children.
+	var detail *StepDetail
+	children := []AnalysisStep{}
+	for _, e := range report.Timeline.Events {
+		if e.StartupStep.ID == id && detail == nil {
+			detail = &StepDetail{EventDetail: eventDetail(e)}
+		}
+		if p := e.StartupStep.ParentID; p != nil && *p == id {
+			children = append(children, analysisStep(e))
+		}
+	}
+	if detail == nil {
+		http.NotFound(w, r)
+		return
+	}
+	detail.Children = children
+	setWarningHeaders(w, report)
+	writeJSON(w, detail)
+}
+
+func handleStepGroup(w http.ResponseWriter, r *http.Request) {
+	// get group.
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "step name is required", http.StatusBadRequest)
+		return
+	}
+	var parentID *int
+	if v := r.URL.Query().Get("parent"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid parent step id", http.StatusBadRequest)
+			return
+		}
+		parentID = &id
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// find the siblings with the name.
+	steps := []AnalysisStep{}
+	for _, e := range report.Timeline.Events {
+		p := e.StartupStep.ParentID
+		if e.StartupStep.Name == name && (p == nil) == (parentID == nil) && (p == nil || *p == *parentID) {
+			steps = append(steps, analysisStep(e))
+		}
+	}
+	setWarningHeaders(w, report)
+	writeJSON(w, steps)
+}
+
+// orderParam returns the child sort order requested with ?sort, defaulting to
+// the server one.
+func orderParam(r *http.Request) (string, error) {
+	order := r.URL.Query().Get("sort")
+	if order == "" {
+		order = defaultPageOptions.Sort
+	}
+	return order, checkOrder(order)
+}
+
+// SubtreeNode represents a step and its descendants.
+type SubtreeNode struct {
+	AnalysisStep
+	SelfMs   float64        `json:"selfMs"`
+	Children []*SubtreeNode `json:"children,omitempty"`
+
+	// Hidden is the number of descendants left out by the depth limit.
+	Hidden int `json:"hidden,omitempty"`
+}
+
+func handleSubtree(w http.ResponseWriter, r *http.Request) {
+	// get step id and depth.
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid step id", http.StatusBadRequest)
+		return
+	}
+	order, err := orderParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	depth := 3
+	if v := r.URL.Query().Get("depth"); v != "" {
+		if depth, err = strconv.Atoi(v); err != nil || depth < 1 {
+			http.Error(w, "invalid depth", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// find step.
+	var step *Node
+	tree := buildTree(report.Timeline)
+	tree.Sort(order)
+	tree.Walk(func(n *Node) bool {
+		if step == nil && n.Event.StartupStep.ID == id {
+			step = n
+		}
+		return step == nil
+	})
+	if step == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// write the subtree down to depth levels below the step.
+	setWarningHeaders(w, report)
+	writeJSON(w, subtree(step, depth))
+}
+
+// subtree converts the step and its descendants down to depth levels below it;
+// 0 converts all levels.
+func subtree(step *Node, depth int) *SubtreeNode {
+	var root *SubtreeNode
+	converted := make(map[*Node]*SubtreeNode)
+	(&Tree{Roots: []*Node{step}}).Walk(func(n *Node) bool {
+		sn := &SubtreeNode{AnalysisStep: analysisStep(n.Event), SelfMs: millis(n.SelfTime)}
+		converted[n] = sn
+		if n == step {
+			root = sn
+		} else {
+			converted[n.Parent].Children = append(converted[n.Parent].Children, sn)
+		}
+		if depth <= 0 || n.Depth-step.Depth < depth-1 {
+			return true
+		}
+		(&Tree{Roots: n.Children}).Walk(func(*Node) bool {
+			sn.Hidden++
+			return true
+		})
+		return false
+	})
+	return root
+}
+
+// ReportTree represents the report with its steps nested by parent.
+type ReportTree struct {
+	SpringBootVersion string         `json:"springBootVersion"`
+	StartTime         time.Time      `json:"startTime"` // in UTC.
+	StartupTimeMs     float64        `json:"startupTimeMs"`
+	Steps             []*SubtreeNode `json:"steps"`
+	Truncation        *Truncation    `json:"truncation,omitempty"`
+}
+
+func handleReportTree(w http.ResponseWriter, r *http.Request) {
+	// get order.
+	order, err := orderParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// convert the tree.
+	t := report.Timeline
+	tree := buildTree(t)
+	tree.Sort(order)
+	rt := ReportTree{
+		SpringBootVersion: report.SpringBootVersion,
+		StartTime:         t.StartTime.UTC(),
+		StartupTimeMs:     millis(t.Duration()),
+		Steps:             []*SubtreeNode{},
+		Truncation:        report.Truncation,
+	}
+	for _, n := range tree.Roots {
+		rt.Steps = append(rt.Steps, subtree(n, 0))
+	}
+	setWarningHeaders(w, report)
+	writeJSON(w, rt)
+}
+
+// EventSelfTime represents an event with the time it spent outside its children.
+type EventSelfTime struct {
+	EventDetail
+	SelfMs float64 `json:"selfMs"`
+}
+
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write csv.
+	if r.URL.Query().Get("format") == "csv" {
+		setWarningHeaders(w, report)
+		w.Header().Set("Content-Type", "text/csv")
+		if err := writeEventsCSV(w, report.Timeline); err != nil {
+			log.Printf("failed to write csv: %s", err)
+		}
+		return
+	}
+
+	// list events, parents before their children.
+	events := []EventSelfTime{}
+	buildTree(report.Timeline).Walk(func(n *Node) bool {
+		events = append(events, EventSelfTime{EventDetail: eventDetail(n.Event), SelfMs: millis(n.SelfTime)})
+		return true
+	})
+	setWarningHeaders(w, report)
+	writeJSON(w, events)
+}
+
+// ReportSummary represents the headline numbers of a report.
+type ReportSummary struct {
+	SpringBootVersion string             `json:"springBootVersion"`
+	StartupTimeMs     float64            `json:"startupTimeMs"`
+	Milestones        AnalysisMilestones `json:"milestones"`
+	Steps             int                `json:"steps"`
+	Phases            []Phase            `json:"phases"`
+	Slowest           []AnalysisStep     `json:"slowest"`
+	Truncation        *Truncation        `json:"truncation,omitempty"`
+}
+
+func handleSummary(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// summarize report.
+	s := report.Summary()
+	summary := ReportSummary{
+		SpringBootVersion: s.SpringBootVersion,
+		StartupTimeMs:     millis(s.StartupTime),
+		Milestones:        analysisMilestones(s.Milestones),
+		Steps:             s.Steps,
+		Phases:            phases(report.Timeline),
+		Slowest:           []AnalysisStep{},
+		Truncation:        report.Truncation,
+	}
+	for _, e := range s.Slowest {
+		summary.Slowest = append(summary.Slowest, analysisStep(e))
+	}
+	setWarningHeaders(w, report)
+	writeJSON(w, summary)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"flag"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// authConfig holds the authentication flags. Without any, routes are open.
+type authConfig struct {
+	user  string
+	pass  string
+	token string
+}
+
+// auth is the authentication of the served routes.
+var auth authConfig
+
+// register registers the authentication flags on the flag set. The password
+// and token default to GOAT_AUTH_PASS and GOAT_AUTH_TOKEN, so they don't show
+// in the process list.
+func (c *authConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.user, "auth-user", "", "basic auth user required on every route, with auth-pass.")
+	fs.StringVar(&c.pass, "auth-pass", os.Getenv("GOAT_AUTH_PASS"), "basic auth password, GOAT_AUTH_PASS by default.")
+	fs.StringVar(&c.token, "auth-token", os.Getenv("GOAT_AUTH_TOKEN"), "bearer token accepted on every route, e.g. for api calls from ci; GOAT_AUTH_TOKEN by default.")
+}
+
+// enabled reports whether authentication is required.
+func (c authConfig) enabled() bool {
+	return c.user != "" || c.token != ""
+}
+
+// authorized reports whether the request carries the basic auth credentials
+// or the bearer token.
+func (c authConfig) authorized(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && c.token != "" {
+		return secureEqual(token, c.token)
+	}
+	if user, pass, ok := r.BasicAuth(); ok && c.user != "" {
+		// both compared, so timing doesn't tell which one is wrong.
+		userOK, passOK := secureEqual(user, c.user), secureEqual(pass, c.pass)
+		return userOK && passOK
+	}
+	return false
+}
+
+// secureEqual compares the strings in constant time, hashing them first so
+// their lengths don't leak either.
+func secureEqual(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// withAuth protects the handler with the configured authentication.
+func withAuth(h http.Handler) http.Handler {
+	if !auth.enabled() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.authorized(r) {
+			if auth.user != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="goat", charset="UTF-8"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// subsystems are key subsystems and the lower case bean type parts of their
+// beans.
+var subsystems = []struct {
+	name  string
+	types []string
+}{
+	{"datasource", []string{"datasource"}},
+	{"web server", []string{"webserverfactory", "webserver"}},
+	{"cache client", []string{"cachemanager", "redisconnectionfactory", "lettuceconnectionfactory", "jedisconnectionfactory"}},
+}
+
+// BeanAvailability represents when the first bean of a type became available.
+type BeanAvailability struct {
+	Type        string       `json:"type"`
+	Step        AnalysisStep `json:"step"`
+	BeanType    string       `json:"beanType"`
+	AvailableMs float64      `json:"availableMs"` // since the startup start.
+}
+
+// firstBean returns the first bean to finish instantiating whose beanType tag
+// contains any of the lower case types.
+func firstBean(t Timeline, types ...string) (Events, bool) {
+	var first Events
+	found := false
+	for _, e := range t.Events {
+		if e.StartupStep.Name != "spring.beans.instantiate" {
+			continue
+		}
+		beanType := strings.ToLower(e.StartupStep.Tag("beanType"))
+		for _, typ := range types {
+			if beanType != "" && strings.Contains(beanType, typ) && (!found || e.EndTime.Before(first.EndTime)) {
+				first, found = e, true
+			}
+		}
+	}
+	return first, found
+}
+
+// beanAvailability returns when the first bean of the type became available.
+func beanAvailability(t Timeline, typ string, types ...string) (BeanAvailability, bool) {
+	e, ok := firstBean(t, types...)
+	if !ok {
+		return BeanAvailability{}, false
+	}
+	return BeanAvailability{
+		Type:        typ,
+		Step:        analysisStep(e),
+		BeanType:    e.StartupStep.Tag("beanType"),
+		AvailableMs: millis(e.EndTime.Sub(t.StartTime)),
+	}, true
+}
+
+func checkBeanAvailability(t Timeline) []Finding {
+	var parts []string
+	var ids []int
+	for _, s := range subsystems {
+		e, ok := firstBean(t, s.types...)
+		if !ok {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s at %s (%s)", s.name, formatDuration(e.EndTime.Sub(t.StartTime)), e.StartupStep.Key()))
+		ids = append(ids, e.StartupStep.ID)
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return []Finding{{
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("first beans available: %s.", strings.Join(parts, ", ")),
+		StepIDs:  ids,
+	}}
+}
+
+func handleFirstBean(w http.ResponseWriter, r *http.Request) {
+	// get bean type.
+	typ := r.URL.Query().Get("type")
+	if typ == "" {
+		http.Error(w, "bean type is required", http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// find bean.
+	a, ok := beanAvailability(report.Timeline, typ, strings.ToLower(typ))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	setWarningHeaders(w, report)
+	writeJSON(w, a)
+}
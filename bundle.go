@@ -0,0 +1,269 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	neturl "net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces the secrets of the bundled configuration.
+const redactedValue = "[redacted]"
+
+// secretName matches the flag and environment names holding secrets.
+var secretName = regexp.MustCompile(`(?i)pass|token|secret|key`)
+
+// VersionInfo represents the build of goat.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// goatVersion returns the build of goat, as recorded by the go toolchain.
+func goatVersion() VersionInfo {
+	v := VersionInfo{Version: "dev", GoVersion: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return v
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		v.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.time":
+			v.Time = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		}
+	}
+	return v
+}
+
+// BundleConfig represents the redacted configuration of a bundle.
+type BundleConfig struct {
+	Flags  map[string]string `json:"flags"`
+	Env    map[string]string `json:"env"`
+	Errors []string          `json:"errors"`
+}
+
+// runBundle implements the bundle command: it packages the reports of the
+// server flags, their analyses, the redacted configuration and the goat
+// version into one archive for support cases.
+func runBundle(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	output := fs.String("o", "goat-bundle.tar.gz", "bundle archive written.")
+	f := registerServerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: goat bundle [-o case.tar.gz] [server flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if err := applyConfigFile(fs, f.configFile); err != nil {
+		log.Fatal(err)
+	}
+	noColor = true
+
+	// get report sources.
+	sources := map[string]string{}
+	var names []string
+	add := func(name, source string) {
+		name = uniqueName(sources, name)
+		sources[name] = source
+		names = append(names, name)
+	}
+	for _, report := range f.reports {
+		add(splitReportFlag(report))
+	}
+	if f.reportsDir != "" {
+		entries, err := os.ReadDir(f.reportsDir)
+		if err != nil {
+			log.Fatalf("failed to read reports directory: %s", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || isReportFile(entry.Name()) {
+				add(reportName(entry.Name()), filepath.Join(f.reportsDir, entry.Name()))
+			}
+		}
+	}
+	if f.url != "" {
+		add("url", f.url)
+	}
+	if len(names) == 0 && stdinPiped() {
+		add("stdin", stdinSource)
+	}
+	if len(names) == 0 {
+		log.Fatal("spring actuator startup report is required!")
+	}
+
+	// write bundle.
+	out, err := os.Create(*output)
+	if err != nil {
+		log.Fatalf("failed to create bundle: %s", err)
+	}
+	b := newBundleWriter(out, strings.TrimSuffix(strings.TrimSuffix(filepath.Base(*output), ".gz"), ".tar"))
+	for _, name := range names {
+		b.report(name, sources[name])
+	}
+	b.json("config.json", bundleConfig(fs, f))
+	b.json("version.json", goatVersion())
+	if err := b.close(); err != nil {
+		log.Fatalf("failed to write bundle: %s", err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatalf("failed to write bundle: %s", err)
+	}
+	fmt.Printf("bundle written to %s.\n", *output)
+}
+
+// uniqueName returns the name, numbered when taken.
+func uniqueName(taken map[string]string, name string) string {
+	unique := name
+	for n := 2; ; n++ {
+		if _, ok := taken[unique]; !ok {
+			return unique
+		}
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+}
+
+// bundleConfig returns the flags set on the command line and the goat
+// environment variables, secrets redacted, and the configuration errors.
+func bundleConfig(fs *flag.FlagSet, f *serverFlags) BundleConfig {
+	c := BundleConfig{Flags: map[string]string{}, Env: map[string]string{}, Errors: []string{}}
+	fs.Visit(func(fl *flag.Flag) {
+		c.Flags[fl.Name] = redact(fl.Name, fl.Value.String())
+	})
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(k, "GOAT_") || k == "NO_COLOR" {
+			c.Env[k] = redact(k, v)
+		}
+	}
+	for _, err := range f.check(false) {
+		c.Errors = append(c.Errors, err.Error())
+	}
+	return c
+}
+
+// redact redacts the values of secret names, and the credentials of urls.
+func redact(name, value string) string {
+	if value != "" && secretName.MatchString(name) {
+		return redactedValue
+	}
+	if u, err := neturl.Parse(value); err == nil && u.User != nil {
+		u.User = neturl.User("redacted")
+		return u.String()
+	}
+	return value
+}
+
+// bundleWriter writes the files of a bundle into a gzipped tar under a
+// top-level directory.
+type bundleWriter struct {
+	gz  *gzip.Writer
+	tw  *tar.Writer
+	dir string
+	err error
+}
+
+func newBundleWriter(out *os.File, dir string) *bundleWriter {
+	gz := gzip.NewWriter(out)
+	return &bundleWriter{gz: gz, tw: tar.NewWriter(gz), dir: dir}
+}
+
+// file writes the file, keeping the first error.
+func (b *bundleWriter) file(name string, content []byte) {
+	if b.err != nil {
+		return
+	}
+	hdr := &tar.Header{Name: path.Join(b.dir, name), Mode: 0o644, Size: int64(len(content)), ModTime: time.Now()}
+	if b.err = b.tw.WriteHeader(hdr); b.err == nil {
+		_, b.err = b.tw.Write(content)
+	}
+}
+
+// json writes v as a json file.
+func (b *bundleWriter) json(name string, v interface{}) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, v); err != nil {
+		b.err = err
+		return
+	}
+	b.file(name, buf.Bytes())
+}
+
+// report writes the raw report of the source and its analysis, or why it
+// failed to load.
+func (b *bundleWriter) report(name, source string) {
+	dir := path.Join("reports", name)
+	content, file, err := rawReport(source)
+	if err != nil {
+		b.file(path.Join(dir, "error.txt"), []byte(err.Error()+"\n"))
+		return
+	}
+	b.file(path.Join(dir, file), content)
+	report, err := decodeReport(context.Background(), content)
+	if err != nil {
+		b.file(path.Join(dir, "error.txt"), []byte(err.Error()+"\n"))
+		return
+	}
+	a, err := analyze(context.Background(), report)
+	if err != nil {
+		b.file(path.Join(dir, "error.txt"), []byte(err.Error()+"\n"))
+		return
+	}
+	var analysis bytes.Buffer
+	printAnalysis(&analysis, report, a, 10)
+	b.file(path.Join(dir, "analysis.txt"), analysis.Bytes())
+	b.json(path.Join(dir, "analysis.json"), a)
+}
+
+// close flushes the archive.
+func (b *bundleWriter) close() error {
+	if b.err != nil {
+		return b.err
+	}
+	if err := b.tw.Close(); err != nil {
+		return err
+	}
+	return b.gz.Close()
+}
+
+// rawReport returns the content of the report source as is, and the file
+// name it is bundled as.
+func rawReport(source string) ([]byte, string, error) {
+	switch {
+	case source == stdinSource:
+		content, err := readReportContent(os.Stdin)
+		return content, "report.json", err
+	case isURL(source):
+		content, _, err := fetchReportContent(context.Background(), source)
+		return content, "report.json", err
+	}
+	p, err := resolveReportFile(source)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := os.ReadFile(p)
+	return content, filepath.Base(p), err
+}
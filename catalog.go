@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+)
+
+// StepInfo describes a known spring startup step.
+type StepInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// TypicalMinMs and TypicalMaxMs are the usual duration range of the step in
+	// a mid-sized application; steps outside of it are worth a look.
+	TypicalMinMs float64 `json:"typicalMinMs"`
+	TypicalMaxMs float64 `json:"typicalMaxMs"`
+}
+
+// stepCatalog are the known spring startup steps, by name.
+var stepCatalog = map[string]StepInfo{}
+
+func init() {
+	for _, info := range []StepInfo{
+		{"spring.boot.application.starting", "SpringApplication started, before the environment is prepared.", 0, 50},
+		{"spring.boot.application.environment-prepared", "Environment prepared: property sources and profiles loaded, before the context is created.", 10, 300},
+		{"spring.boot.application.context-prepared", "Application context created and prepared, before sources are loaded.", 0, 50},
+		{"spring.boot.application.context-loaded", "Application sources loaded into the context, before it is refreshed.", 0, 100},
+		{"spring.boot.application.started", "Context refreshed, before application and command line runners are called.", 0, 50},
+		{"spring.boot.application.ready", "Runners called; the application is ready to serve requests.", 0, 500},
+		{"spring.boot.application.failed", "Startup failed; tagged with the exception.", 0, 0},
+		{"spring.context.refresh", "Application context refresh: bean definitions processed and singletons instantiated. Usually the bulk of startup.", 500, 10000},
+		{"spring.context.beans.post-process", "BeanFactoryPostProcessor beans invoked, e.g. configuration class parsing.", 50, 2000},
+		{"spring.context.beandef-registry.post-process", "A BeanDefinitionRegistryPostProcessor registering bean definitions.", 10, 1500},
+		{"spring.context.bean-factory.post-process", "A BeanFactoryPostProcessor adjusting bean definitions.", 0, 200},
+		{"spring.context.config-classes.parse", "@Configuration classes parsed and component scanning run.", 50, 1500},
+		{"spring.context.config-classes.enhance", "@Configuration classes enhanced with CGLIB proxies.", 0, 200},
+		{"spring.context.component-classes.register", "Component classes registered on an annotation config context.", 0, 100},
+		{"spring.context.annotated-bean-reader.create", "AnnotatedBeanDefinitionReader created.", 0, 50},
+		{"spring.beans.instantiate", "A bean instantiated with its dependencies; tagged with the bean name and type.", 0, 500},
+		{"spring.beans.smart-initialize", "SmartInitializingSingleton callbacks of a bean after all singletons are created.", 0, 200},
+		{"spring.data.repository.scanning", "Spring Data repository interfaces scanned.", 0, 500},
+		{"spring.data.repository.init", "A Spring Data repository initialized.", 0, 200},
+	} {
+		stepCatalog[info.Name] = info
+	}
+}
+
+// describeStep returns the catalog description of the step name, if known.
+func describeStep(name string) string {
+	return stepCatalog[name].Description
+}
+
+func handleStepCatalog(w http.ResponseWriter, r *http.Request) {
+	catalog := make([]StepInfo, 0, len(stepCatalog))
+	for _, info := range stepCatalog {
+		catalog = append(catalog, info)
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Name < catalog[j].Name
+	})
+	writeJSON(w, catalog)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// runCheck implements the check command: it exits non-zero when the startup
+// time or a step exceed their budgets, so reports can gate CI pipelines.
+func runCheck(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	path := fs.String("report", "", "spring actuator startup report, file, directory, url or - for stdin. required!")
+	maxStartup := fs.Duration("max-startup", 0, "startup time budget; 0 disables the check.")
+	maxStep := fs.Duration("max-step", 0, "budget of the duration of every step, children included; 0 disables the check.")
+	maxStepSelf := fs.Duration("max-step-self", 0, "budget of the self time of every step, excluding its children; 0 disables the check.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerColorFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *path == "" {
+		log.Fatal("startup report is required!")
+	}
+	if *maxStartup == 0 && *maxStep == 0 && *maxStepSelf == 0 {
+		log.Fatal("at least one of max-startup, max-step or max-step-self is required!")
+	}
+
+	// get report.
+	report, err := loadReport(context.Background(), *path)
+	if err != nil {
+		log.Fatalf("failed to load report: %s", err)
+	}
+
+	// check budgets.
+	if !checkBudgets(os.Stdout, report.Timeline, *maxStartup, *maxStep, *maxStepSelf) {
+		os.Exit(1)
+	}
+}
+
+// checkBudgets prints the startup time and the steps over their budgets, and
+// reports whether all budgets are met.
+func checkBudgets(w io.Writer, t Timeline, maxStartup, maxStep, maxStepSelf time.Duration) bool {
+	ok := true
+
+	// startup time.
+	total := t.Duration()
+	if maxStartup > 0 && total > maxStartup {
+		ok = false
+		fmt.Fprintf(w, "FAIL: startup time %s exceeds the %s budget.\n", colorByDuration(formatDuration(total), total), formatDuration(maxStartup))
+	} else {
+		fmt.Fprintf(w, "OK: startup time %s.\n", formatDuration(total))
+	}
+
+	// steps.
+	tree := buildTree(t)
+	if maxStep > 0 && !checkStepBudget(w, tree, maxStep, "", func(n *Node) time.Duration { return n.Event.Duration() }) {
+		ok = false
+	}
+	if maxStepSelf > 0 && !checkStepBudget(w, tree, maxStepSelf, "self time ", func(n *Node) time.Duration { return n.SelfTime }) {
+		ok = false
+	}
+	return ok
+}
+
+// checkStepBudget prints the steps whose measure exceeds the budget, slowest
+// first, and reports whether none does.
+func checkStepBudget(w io.Writer, tree *Tree, budget time.Duration, what string, measure func(*Node) time.Duration) bool {
+	var slow []*Node
+	tree.Walk(func(n *Node) bool {
+		if measure(n) > budget {
+			slow = append(slow, n)
+		}
+		return true
+	})
+	sort.SliceStable(slow, func(i, j int) bool {
+		return measure(slow[i]) > measure(slow[j])
+	})
+	if len(slow) == 0 {
+		fmt.Fprintf(w, "OK: no step exceeds the %s%s budget.\n", what, formatDuration(budget))
+		return true
+	}
+	fmt.Fprintf(w, "FAIL: %d steps exceed the %s%s budget:\n", len(slow), what, formatDuration(budget))
+	for _, n := range slow {
+		d := measure(n)
+		fmt.Fprintf(w, "  [%d] %s: %s\n", n.Event.StartupStep.ID, n.Event.StartupStep.Key(), colorByDuration(formatDuration(d), d))
+	}
+	return false
+}
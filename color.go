@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+)
+
+// noColor disables colored terminal output.
+var noColor bool
+
+// levelColors are the ansi colors of the duration levels.
+var levelColors = map[string]string{
+	"danger":  "\x1b[31m",
+	"warning": "\x1b[33m",
+	"success": "\x1b[32m",
+}
+
+// registerColorFlags registers the terminal color flags in the flag set.
+func registerColorFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&noColor, "no-color", false, "disable colored output, which is on by default when writing to a terminal.")
+}
+
+// useColor reports whether the output should be colored: only when stdout is
+// a terminal, unless disabled by -no-color or NO_COLOR.
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorByDuration colors s with the level of the duration, using the same
+// thresholds as the web page badges.
+func colorByDuration(s string, d time.Duration) string {
+	if !useColor() {
+		return s
+	}
+	return levelColors[levelBasedOnDuration(d)] + s + "\x1b[0m"
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runComment implements the comment command: it compares two reports and
+// prints a ready-to-post pull request comment.
+func runComment(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("comment", flag.ExitOnError)
+	basePath := fs.String("base", "", "base (old) startup report. required!")
+	headPath := fs.String("head", "", "head (new) startup report. required!")
+	format := fs.String("format", "github", "comment format: github.")
+	threshold := fs.Duration("threshold", 100*time.Millisecond, "minimum step delta to be reported.")
+	limit := fs.Int("limit", 10, "maximum number of steps listed per section.")
+	serverURL := fs.String("server", "", "url of a goat server serving the head report, used to embed its badge, timeline image and flame graph link.")
+	var summarizerConf summarizerConfig
+	summarizerConf.register(fs)
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *basePath == "" || *headPath == "" {
+		log.Fatal("base and head startup reports are required!")
+	}
+	if *format != "github" {
+		log.Fatalf("unsupported comment format: %s", *format)
+	}
+
+	// get reports.
+	base, err := loadReport(context.Background(), *basePath)
+	if err != nil {
+		log.Fatalf("failed to unmarshal base report: %s", err)
+	}
+	head, err := loadReport(context.Background(), *headPath)
+	if err != nil {
+		log.Fatalf("failed to unmarshal head report: %s", err)
+	}
+
+	// summarize comparison.
+	c := compareReports(base, head)
+	if s := summarizerConf.summarizer(); s != nil {
+		summary, err := s.Summarize(context.Background(), comparisonPrompt(c, *threshold))
+		if err != nil {
+			log.Printf("failed to summarize comparison: %s", err)
+		} else {
+			fmt.Printf("> %s\n\n", summary)
+		}
+	}
+
+	// write comment.
+	writeGithubComment(os.Stdout, c, *threshold, *limit, *serverURL)
+}
+
+// writeGithubComment writes the comparison as GitHub flavored markdown.
+func writeGithubComment(w io.Writer, c Comparison, threshold time.Duration, limit int, serverURL string) {
+	baseTotal, headTotal := c.Base.Timeline.Duration(), c.Head.Timeline.Duration()
+
+	// totals.
+	fmt.Fprintf(w, "## Startup time comparison\n\n")
+	fmt.Fprintf(w, "| | Base | Head | Delta |\n|---|---:|---:|---:|\n")
+	fmt.Fprintf(w, "| **Startup time** | %s | %s | %s |\n", formatDuration(baseTotal), formatDuration(headTotal), formatDelta(c.TotalDelta(), baseTotal))
+	fmt.Fprintf(w, "| Events | %d | %d | %+d |\n\n", len(c.Base.Timeline.Events), len(c.Head.Timeline.Events), len(c.Head.Timeline.Events)-len(c.Base.Timeline.Events))
+
+	// regressions.
+	regressions := c.Regressions(threshold)
+	if len(regressions) == 0 {
+		fmt.Fprintf(w, "No step got slower by more than %s. :tada:\n\n", threshold)
+	} else {
+		fmt.Fprintf(w, "### Regressions\n\n")
+		writeStepTable(w, regressions, limit)
+	}
+
+	// details.
+	writeStepDetails(w, "Improvements", c.Improvements(threshold), limit)
+	writeStepDetails(w, "New steps", c.WithStatus(StepAdded), limit)
+	writeStepDetails(w, "Removed steps", c.WithStatus(StepRemoved), limit)
+
+	// server links.
+	if serverURL != "" {
+		serverURL = strings.TrimSuffix(serverURL, "/")
+		fmt.Fprintf(w, "![startup time](%s/badge.svg)\n\n", serverURL)
+		fmt.Fprintf(w, "![startup timeline](%s/timeline.svg)\n\n", serverURL)
+		fmt.Fprintf(w, "[Flame graph](%s/flamegraph)\n", serverURL)
+	}
+}
+
+func writeStepDetails(w io.Writer, title string, steps []StepDiff, limit int) {
+	if len(steps) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "<details>\n<summary>%s (%d)</summary>\n\n", title, len(steps))
+	writeStepTable(w, steps, limit)
+	fmt.Fprintf(w, "</details>\n\n")
+}
+
+func writeStepTable(w io.Writer, steps []StepDiff, limit int) {
+	fmt.Fprintf(w, "| Step | Base | Head | Delta |\n|---|---:|---:|---:|\n")
+	for i, d := range steps {
+		if i == limit {
+			fmt.Fprintf(w, "| _and %d more..._ | | | |\n", len(steps)-limit)
+			break
+		}
+		fmt.Fprintf(w, "| `%s` | %s | %s | %s |\n", strings.ReplaceAll(d.Key, "|", `\|`), formatDuration(d.Base), formatDuration(d.Head), formatDelta(d.Delta(), d.Base))
+	}
+	fmt.Fprintln(w)
+}
+
+// formatDuration formats a duration rounded to milliseconds.
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// formatDelta formats a signed duration change, with the relative change when
+// the original duration is known.
+func formatDelta(delta, from time.Duration) string {
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	s := sign + formatDuration(delta)
+	if from > 0 {
+		s += fmt.Sprintf(" (%s%.1f%%)", sign, 100*float64(delta)/float64(from))
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Step diff status.
+const (
+	StepChanged = "changed"
+	StepAdded   = "added"
+	StepRemoved = "removed"
+)
+
+// StepDiff represents the duration change of a step between two reports.
+type StepDiff struct {
+	Key    string
+	Status string
+	Base   time.Duration
+	Head   time.Duration
+}
+
+// Delta returns the duration change from base to head.
+func (d StepDiff) Delta() time.Duration {
+	return d.Head - d.Base
+}
+
+// Comparison represents the comparison of two startup reports.
+type Comparison struct {
+	Base  *StartupReport
+	Head  *StartupReport
+	Steps []StepDiff
+}
+
+// TotalDelta returns the startup time change from base to head.
+func (c Comparison) TotalDelta() time.Duration {
+	return c.Head.Timeline.Duration() - c.Base.Timeline.Duration()
+}
+
+// Regressions returns the steps of both reports that got slower by more than
+// min, slowest first. Added steps are left to WithStatus.
+func (c Comparison) Regressions(min time.Duration) []StepDiff {
+	return c.filter(func(d StepDiff) bool {
+		return d.Status == StepChanged && d.Delta() > min
+	})
+}
+
+// Improvements returns the steps of both reports that got faster by more than
+// min, fastest first. Removed steps are left to WithStatus.
+func (c Comparison) Improvements(min time.Duration) []StepDiff {
+	steps := c.filter(func(d StepDiff) bool {
+		return d.Status == StepChanged && -d.Delta() > min
+	})
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Delta() < steps[j].Delta()
+	})
+	return steps
+}
+
+// WithStatus returns the steps with the given status, slowest first.
+func (c Comparison) WithStatus(status string) []StepDiff {
+	return c.filter(func(d StepDiff) bool {
+		return d.Status == status
+	})
+}
+
+func (c Comparison) filter(keep func(StepDiff) bool) []StepDiff {
+	var steps []StepDiff
+	for _, d := range c.Steps {
+		if keep(d) {
+			steps = append(steps, d)
+		}
+	}
+	return steps
+}
+
+// compareReports compares the steps of two reports. Steps sharing the same
+// key are summed up, so repeated steps are compared as a whole.
+func compareReports(base, head *StartupReport) Comparison {
+	// sum durations by step key.
+	sum := func(report *StartupReport) (map[string]time.Duration, []string) {
+		durations := make(map[string]time.Duration)
+		var keys []string
+		for _, e := range report.Timeline.Events {
+			key := e.StartupStep.Key()
+			if _, ok := durations[key]; !ok {
+				keys = append(keys, key)
+			}
+			durations[key] += e.Duration()
+		}
+		return durations, keys
+	}
+	baseDurations, baseKeys := sum(base)
+	headDurations, headKeys := sum(head)
+
+	// diff steps.
+	var steps []StepDiff
+	for _, key := range headKeys {
+		d := StepDiff{Key: key, Status: StepChanged, Head: headDurations[key]}
+		if b, ok := baseDurations[key]; ok {
+			d.Base = b
+		} else {
+			d.Status = StepAdded
+		}
+		steps = append(steps, d)
+	}
+	for _, key := range baseKeys {
+		if _, ok := headDurations[key]; !ok {
+			steps = append(steps, StepDiff{Key: key, Status: StepRemoved, Base: baseDurations[key]})
+		}
+	}
+
+	// biggest regressions first.
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Delta() > steps[j].Delta()
+	})
+	return Comparison{Base: base, Head: head, Steps: steps}
+}
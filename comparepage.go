@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// compareSource is the report the served one is compared against on the
+// comparison page, "" disables the page.
+var compareSource string
+
+func handleCompare(w http.ResponseWriter, r *http.Request) {
+	// get threshold.
+	threshold, err := durationParam(r, "threshold", 100*time.Millisecond)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get reports.
+	base, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	head, err := loadReport(r.Context(), compareSource)
+	if err != nil {
+		log.Printf("failed to unmarshal compared report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// render page.
+	page, err := renderComparePage(compareReports(base.StartupReport, head), threshold)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// set html content type.
+	setWarningHeaders(w, base)
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// renderComparePage renders the comparison page, listing the steps that changed
+// by more than threshold.
+func renderComparePage(c Comparison, threshold time.Duration) ([]byte, error) {
+	// set funcs.
+	funcs := template.FuncMap{
+		"formatDelta": formatDelta,
+		"deltaClass": func(delta time.Duration) string {
+			if delta > 0 {
+				return "badge-danger"
+			}
+			return "badge-success"
+		},
+		"section": func(title string, steps []StepDiff) interface{} {
+			return struct {
+				Title string
+				Steps []StepDiff
+			}{title, steps}
+		},
+	}
+
+	// load template.
+	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/compare.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// render template.
+	view := struct {
+		Comparison
+		Threshold time.Duration
+	}{c, threshold}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "compare.html", view); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
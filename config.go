@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// runConfig implements the config command: config validate checks the server
+// flags, reports and all, and reports every error without serving.
+func runConfig(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("config validate", flag.ExitOnError)
+	f := registerServerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: goat config validate [server flags]\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "validate" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fs.Parse(args[1:])
+	var errs []error
+	if err := applyConfigFile(fs, f.configFile); err != nil {
+		errs = append(errs, err)
+	}
+
+	// check configs.
+	errs = append(errs, f.check(true)...)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%d configuration errors.\n", len(errs))
+		os.Exit(1)
+	}
+	fmt.Println("configuration is valid.")
+}
+
+// check returns all the errors of the configs. With loadReports, the report
+// sources are loaded too, but actuator urls are not fetched.
+func (f *serverFlags) check(loadReports bool) []error {
+	var errs []error
+	checkReportSource := func(flag, source string) []error {
+		if !loadReports {
+			return nil
+		}
+		return checkReportSource(flag, source)
+	}
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	// check addresses.
+	addrs := map[string]string{"port": ":" + serverPort, "addr": serverAddr, "api-addr": apiAddr}
+	for _, name := range sortedKeys(addrs) {
+		if addrs[name] == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addrs[name]); err != nil {
+			fail("invalid %s: %s", name, err)
+		}
+	}
+
+	// check options.
+	if err := checkOrder(defaultPageOptions.Sort); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkTheme(pageTheme); err != nil {
+		errs = append(errs, err)
+	}
+	counts := map[string]int{"group-above": defaultPageOptions.GroupAbove, "max-depth": defaultPageOptions.MaxDepth, "max-events": maxEvents}
+	for _, name := range sortedKeys(counts) {
+		if counts[name] < 0 {
+			fail("invalid %s: %d is negative", name, counts[name])
+		}
+	}
+	if requestTimeout < 0 || f.pollInterval < 0 || processingTimeout < 0 || readTimeout < 0 || writeTimeout < 0 || idleTimeout < 0 || shutdownTimeout < 0 || pageRefresh < 0 {
+		fail("durations can't be negative")
+	}
+	if f.summarizer.url != "" && !isURL(f.summarizer.url) {
+		fail("invalid summarizer-url: %s", f.summarizer.url)
+	}
+	if telemetry.endpoint != "" && !isURL(telemetry.endpoint) {
+		fail("invalid otel-endpoint: %s", telemetry.endpoint)
+	}
+
+	// check auth.
+	if auth.user != "" && auth.pass == "" {
+		fail("auth-user requires auth-pass")
+	}
+
+	// check tls.
+	errs = append(errs, serverTLS.check()...)
+
+	// check history.
+	if _, err := parseLabels(f.labels); err != nil {
+		errs = append(errs, err)
+	}
+	if f.historyPath != "" {
+		if _, err := os.Stat(filepath.Dir(f.historyPath)); err != nil {
+			fail("invalid history: %s", err)
+		}
+	}
+
+	// check stream.
+	if f.secrets != "" {
+		if !f.stream && f.historyPath == "" {
+			fail("stream-secrets requires stream or history")
+		} else if _, err := loadSecrets(f.secrets); err != nil {
+			fail("failed to load stream secrets: %s", err)
+		}
+	}
+	if f.stream {
+		return errs
+	}
+
+	// check report sources.
+	if compareSource != "" {
+		errs = append(errs, checkReportSource("compare", compareSource)...)
+	}
+	if len(f.reports) > 1 || f.reportsDir != "" {
+		if f.url != "" || f.watch || snapshotPages {
+			fail("url, watch and snapshot serve a single report!")
+		}
+		for _, report := range f.reports {
+			_, path := splitReportFlag(report)
+			errs = append(errs, checkReportSource("report", path)...)
+		}
+		if f.reportsDir != "" {
+			entries, err := os.ReadDir(f.reportsDir)
+			if err != nil && loadReports {
+				fail("failed to read reports directory: %s", err)
+			}
+			for _, entry := range entries {
+				if entry.IsDir() || isReportFile(entry.Name()) {
+					errs = append(errs, checkReportSource("reports-dir", filepath.Join(f.reportsDir, entry.Name()))...)
+				}
+			}
+		}
+		return errs
+	}
+	var path string
+	if len(f.reports) == 1 {
+		_, path = splitReportFlag(f.reports[0])
+	}
+	switch {
+	case len(f.reports) == 1 && f.url != "":
+		fail("only one of report or url can be set!")
+	case f.url != "" && !isURL(f.url):
+		fail("invalid actuator url: %s", f.url)
+	case len(f.reports) == 1:
+		errs = append(errs, checkReportSource("report", path)...)
+	case f.url == "" && !stdinPiped():
+		fail("spring actuator startup report is required!")
+	}
+	if f.watch && (f.url != "" || len(f.reports) == 0 || path == stdinSource || isURL(path)) {
+		fail("watch requires a report file!")
+	}
+	return errs
+}
+
+// checkReportSource loads the report of the flag; stdin and actuator urls are
+// left to the server.
+func checkReportSource(flag, source string) []error {
+	if source == stdinSource || isURL(source) {
+		return nil
+	}
+	if _, err := loadReport(context.Background(), source); err != nil {
+		return []error{fmt.Errorf("invalid %s %s: %w", flag, source, err)}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFiles are the config files read from the working directory
+// when -config isn't set.
+var defaultConfigFiles = []string{"goat.yaml", "goat.yml", "goat.toml"}
+
+// applyConfigFile sets the flags the command line didn't set from the yaml or
+// toml config file, whose keys are flag names, e.g. port: 8080 or auth-user:
+// admin. Lists set repeated flags, e.g. report: [a=a.json, b=b.json]. Without
+// a path, the default config file is read if present.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	// find config file.
+	if path == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+		if path == "" {
+			return nil
+		}
+	}
+
+	// parse config file.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// set flags; the command line overrides the file.
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	var errs []error
+	for _, name := range sortedKeys(values) {
+		if fs.Lookup(name) == nil || name == "config" {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, name))
+			continue
+		}
+		if set[name] {
+			continue
+		}
+		list, ok := values[name].([]interface{})
+		if !ok {
+			list = []interface{}{values[name]}
+		}
+		for _, v := range list {
+			if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid %s: %w", path, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeEventsCSV writes the events in report order as csv, one row per event
+// with its tags flattened into key=value pairs separated by semicolons and the
+// step catalog description.
+func writeEventsCSV(w io.Writer, t Timeline) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "id", "parentId", "startTime", "endTime", "durationMs", "tags", "description"}); err != nil {
+		return err
+	}
+	for _, e := range t.Events {
+		parentID := ""
+		if p := e.StartupStep.ParentID; p != nil {
+			parentID = strconv.Itoa(*p)
+		}
+		var tags []string
+		for _, tag := range e.StartupStep.Tags {
+			tags = append(tags, tag.Key+"="+tag.Value)
+		}
+		record := []string{
+			e.StartupStep.Name,
+			strconv.Itoa(e.StartupStep.ID),
+			parentID,
+			e.StartTime.UTC().Format(time.RFC3339Nano),
+			e.EndTime.UTC().Format(time.RFC3339Nano),
+			strconv.FormatFloat(millis(e.Duration()), 'f', -1, 64),
+			strings.Join(tags, ";"),
+			describeStep(e.StartupStep.Name),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
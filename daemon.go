@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writePIDFile writes the process pid to the file. A pid file left by a
+// process that is no longer running is replaced.
+func writePIDFile(path string) error {
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err == nil && pid != os.Getpid() && processRunning(pid) {
+			return fmt.Errorf("goat is already running with pid %d, see %s", pid, path)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+}
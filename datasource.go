@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// isSQLInitStep reports whether the step is about the bean running the
+// spring.sql.init schema and data scripts.
+func isSQLInitStep(s StartupStep) bool {
+	class := strings.ToLower(s.Tag("beanType") + " " + s.Tag("beanName"))
+	return strings.Contains(class, "scriptdatabaseinitializer")
+}
+
+// isDataSourceStep reports whether the step is about a datasource or connection
+// pool bean.
+func isDataSourceStep(s StartupStep) bool {
+	class := strings.ToLower(s.Tag("beanType") + " " + s.Tag("beanName"))
+	return !isSQLInitStep(s) && (strings.Contains(class, "datasource") || strings.Contains(class, "hikari"))
+}
+
+// outermostSteps returns the outermost steps matching the predicate and their
+// total duration.
+func outermostSteps(t Timeline, match func(StartupStep) bool) ([]*Node, time.Duration) {
+	var nodes []*Node
+	var total time.Duration
+	buildTree(t).Walk(func(n *Node) bool {
+		if !match(n.Event.StartupStep) {
+			return true
+		}
+		nodes = append(nodes, n)
+		total += n.Event.Duration()
+		return false
+	})
+	return nodes, total
+}
+
+func checkDataSourceInit(t Timeline) []Finding {
+	nodes, total := outermostSteps(t, isDataSourceStep)
+	if len(nodes) == 0 {
+		return nil
+	}
+	var ids []int
+	for _, n := range nodes {
+		ids = append(ids, n.Event.StartupStep.ID)
+	}
+	findings := []Finding{{
+		Severity:   SeverityInfo,
+		Message:    fmt.Sprintf("datasources and connection pools took %s to initialize and warm up.", formatDuration(total)),
+		StepIDs:    ids,
+		DurationMs: millis(total),
+	}}
+
+	// pools connecting eagerly block startup until the database answers.
+	for _, n := range nodes {
+		if n.SelfTime < time.Second {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s took %s; startup blocks until the database is reachable, consider spring.datasource.hikari.initialization-fail-timeout=-1 and a lower spring.datasource.hikari.minimum-idle so the pool connects after startup.", n.Event.StartupStep.Key(), formatDuration(n.SelfTime)),
+			StepIDs:    []int{n.Event.StartupStep.ID},
+			DurationMs: millis(n.SelfTime),
+		})
+	}
+	return findings
+}
+
+func checkSQLInit(t Timeline) []Finding {
+	nodes, total := outermostSteps(t, isSQLInitStep)
+	if len(nodes) == 0 {
+		return nil
+	}
+	var ids []int
+	for _, n := range nodes {
+		ids = append(ids, n.Event.StartupStep.ID)
+	}
+	return []Finding{{
+		Severity:   SeverityInfo,
+		Message:    fmt.Sprintf("sql init scripts took %s and run on every boot; set spring.sql.init.mode=never where the schema is managed elsewhere.", formatDuration(total)),
+		StepIDs:    ids,
+		DurationMs: millis(total),
+	}}
+}
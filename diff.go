@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// runDiff implements the diff command: it fetches two live actuator startup
+// endpoints, e.g. of a canary and a stable deployment, and compares them.
+func runDiff(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	urlA := fs.String("url-a", "", "base (old) actuator startup endpoint. required!")
+	urlB := fs.String("url-b", "", "head (new) actuator startup endpoint. required!")
+	format := fs.String("format", "text", "output format: text or github.")
+	threshold := fs.Duration("threshold", 100*time.Millisecond, "minimum step delta to be reported.")
+	limit := fs.Int("limit", 10, "maximum number of steps listed per section.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerColorFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *urlA == "" || *urlB == "" {
+		log.Fatal("url-a and url-b are required!")
+	}
+	if *format != "text" && *format != "github" {
+		log.Fatalf("unsupported diff format: %s", *format)
+	}
+
+	// get reports.
+	base, err := fetchReport(context.Background(), *urlA)
+	if err != nil {
+		log.Fatalf("failed to fetch report from %s: %s", *urlA, err)
+	}
+	head, err := fetchReport(context.Background(), *urlB)
+	if err != nil {
+		log.Fatalf("failed to fetch report from %s: %s", *urlB, err)
+	}
+
+	// write comparison.
+	c := compareReports(base, head)
+	if *format == "github" {
+		writeGithubComment(os.Stdout, c, *threshold, *limit, "")
+		return
+	}
+	printComparison(os.Stdout, c, *threshold, *limit)
+}
+
+// printComparison prints the startup times and the steps that changed by more
+// than threshold, biggest regressions first.
+func printComparison(w io.Writer, c Comparison, threshold time.Duration, limit int) {
+	baseTotal, headTotal := c.Base.Timeline.Duration(), c.Head.Timeline.Duration()
+	fmt.Fprintf(w, "STARTUP TIME: %s -> %s (%s)\n", formatDuration(baseTotal), colorByDuration(formatDuration(headTotal), headTotal), formatDelta(c.TotalDelta(), baseTotal))
+	fmt.Fprintf(w, "EVENTS: %d -> %d\n", len(c.Base.Timeline.Events), len(c.Head.Timeline.Events))
+
+	printSteps := func(title string, steps []StepDiff) {
+		if len(steps) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s (%d):\n", title, len(steps))
+		for i, d := range steps {
+			if i == limit {
+				fmt.Fprintf(w, "  and %d more...\n", len(steps)-limit)
+				break
+			}
+			fmt.Fprintf(w, "  %10s  %s: %s -> %s\n", formatDelta(d.Delta(), 0), d.Key, formatDuration(d.Base), colorByDuration(formatDuration(d.Head), d.Head))
+		}
+	}
+	printSteps("REGRESSIONS", c.Regressions(threshold))
+	printSteps("IMPROVEMENTS", c.Improvements(threshold))
+	printSteps("NEW STEPS", c.WithStatus(StepAdded))
+	printSteps("REMOVED STEPS", c.WithStatus(StepRemoved))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"os"
+)
+
+// runExport implements the export command: it renders the report page into a
+// self-contained html file, viewed without a server, writes the events as csv,
+// or converts the steps into an otlp trace.
+func runExport(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	path := fs.String("report", "", "spring actuator startup report, file, directory, url or - for stdin. required!")
+	output := fs.String("output", "", "exported file, report.<format> by default; - writes to stdout.")
+	format := fs.String("format", "html", "export format: html, csv, otlp, an otlp/json trace of the steps, or goat, goat's normalized report, which imports back as a report.")
+	endpoint := fs.String("otlp-endpoint", "", "otlp/http collector url (e.g. http://localhost:4318) the otlp trace is pushed to instead of written.")
+	serviceName := fs.String("otlp-service-name", "spring-boot", "service name of the otlp trace.")
+	opts := pageOptions{Standalone: true}
+	fs.DurationVar(&opts.CollapseBelow, "collapse-below", 0, "fold the steps shorter than it into one step per parent; 0 disables folding.")
+	fs.StringVar(&opts.Sort, "sort", OrderReport, "children order: report, start or duration.")
+	registerThemeFlags(fs)
+	var summarizerConf summarizerConfig
+	summarizerConf.register(fs)
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *path == "" {
+		log.Fatal("startup report is required!")
+	}
+	if *format != "html" && *format != "csv" && *format != "otlp" && *format != "goat" {
+		log.Fatalf("unsupported export format: %s", *format)
+	}
+	if err := checkTheme(pageTheme); err != nil {
+		log.Fatal(err)
+	}
+	if *endpoint != "" && *format != "otlp" {
+		log.Fatal("otlp-endpoint requires the otlp format!")
+	}
+	if *output == "" {
+		*output = "report." + *format
+		if *format == "otlp" || *format == "goat" {
+			*output = "report." + *format + ".json"
+		}
+	}
+	if err := checkOrder(opts.Sort); err != nil {
+		log.Fatal(err)
+	}
+	summarizer = summarizerConf.summarizer()
+
+	// get report.
+	report, err := loadReport(context.Background(), *path)
+	if err != nil {
+		log.Fatalf("failed to load report: %s", err)
+	}
+
+	// export report; expand links need the server, so every step of the page
+	// is rendered.
+	var page []byte
+	switch *format {
+	case "otlp":
+		trace := otlpTrace(report, *serviceName)
+		if *endpoint != "" {
+			if err := pushTrace(context.Background(), *endpoint, trace); err != nil {
+				log.Fatalf("failed to push trace: %s", err)
+			}
+			return
+		}
+		page, err = otlpJSON(trace)
+	case "goat":
+		var g GoatReport
+		if g, err = goatReport(context.Background(), report); err == nil {
+			var buf bytes.Buffer
+			err = encodeJSON(&buf, g)
+			page = buf.Bytes()
+		}
+	case "csv":
+		var buf bytes.Buffer
+		err = writeEventsCSV(&buf, report.Timeline)
+		page = buf.Bytes()
+	default:
+		page, err = renderReportPage(context.Background(), &ServedReport{StartupReport: report}, opts)
+	}
+	if err != nil {
+		log.Fatalf("failed to export report: %s", err)
+	}
+
+	// write page.
+	if *output == "-" {
+		os.Stdout.Write(page)
+		return
+	}
+	if err := os.WriteFile(*output, page, 0o644); err != nil {
+		log.Fatalf("failed to write export: %s", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Failure represents a failed startup: the step it failed at and why.
+type Failure struct {
+	// Step is the failing step, Chain its ancestors from the top-level step
+	// down to its parent.
+	Step  Events
+	Chain []Events
+
+	// Exceptions are the exception tags of the failing step.
+	Exceptions []Tags
+
+	// Unfinished is set when the failing step never ended.
+	Unfinished bool
+}
+
+// exceptionTags returns the step tags describing an exception or error.
+func exceptionTags(s StartupStep) []Tags {
+	var tags []Tags
+	for _, t := range s.Tags {
+		key := strings.ToLower(t.Key)
+		if strings.Contains(key, "exception") || strings.Contains(key, "error") {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// detectFailure returns the startup failure, or nil if startup didn't fail.
+// The failing step is the deepest one tagged with an exception or, without
+// exception tags, the deepest one that never ended.
+func detectFailure(t Timeline) *Failure {
+	var failing *Node
+	var failingExceptions []Tags
+	buildTree(t).Walk(func(n *Node) bool {
+		exceptions := exceptionTags(n.Event.StartupStep)
+		unfinished := n.Event.EndTime.IsZero()
+		switch {
+		case len(exceptions) > 0 && (len(failingExceptions) == 0 || n.Depth > failing.Depth):
+			failing, failingExceptions = n, exceptions
+		case unfinished && len(failingExceptions) == 0 && (failing == nil || n.Depth > failing.Depth):
+			failing = n
+		}
+		return true
+	})
+	if failing == nil {
+		return nil
+	}
+
+	f := &Failure{Step: failing.Event, Exceptions: failingExceptions, Unfinished: failing.Event.EndTime.IsZero()}
+	for p := failing.Parent; p != nil; p = p.Parent {
+		f.Chain = append([]Events{p.Event}, f.Chain...)
+	}
+	return f
+}
+
+func checkStartupFailure(t Timeline) []Finding {
+	f := detectFailure(t)
+	if f == nil {
+		return nil
+	}
+	message := fmt.Sprintf("startup failed at %s", f.Step.StartupStep.Key())
+	for _, e := range f.Exceptions {
+		message += fmt.Sprintf("; %s: %s", e.Key, e.Value)
+	}
+	if len(f.Exceptions) == 0 && f.Unfinished {
+		message += ", which never ended"
+	}
+	ids := []int{f.Step.StartupStep.ID}
+	for _, e := range f.Chain {
+		ids = append(ids, e.StartupStep.ID)
+	}
+	return []Finding{{
+		Severity: SeverityCritical,
+		Message:  message + ".",
+		StepIDs:  ids,
+	}}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// stepFilter narrows the report page down to the matching steps, kept with
+// their ancestors for context. The zero filter matches every step.
+type stepFilter struct {
+	// Name is matched case-insensitively against the step names.
+	Name string
+
+	// MinDuration is the minimum step duration.
+	MinDuration time.Duration
+
+	// Tag is a key:value tag, the value matched as a substring, or a tag key.
+	Tag string
+}
+
+// active reports whether the filter narrows anything down.
+func (f stepFilter) active() bool {
+	return f != stepFilter{}
+}
+
+// String describes the filter.
+func (f stepFilter) String() string {
+	var parts []string
+	if f.Name != "" {
+		parts = append(parts, fmt.Sprintf("name %q", f.Name))
+	}
+	if f.MinDuration > 0 {
+		parts = append(parts, fmt.Sprintf("at least %s", formatDuration(f.MinDuration)))
+	}
+	if f.Tag != "" {
+		parts = append(parts, fmt.Sprintf("tag %q", f.Tag))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseStepFilter reads the ?name, ?minDuration and ?tag parameters, on top of
+// the filter of the view.
+func parseStepFilter(r *http.Request, f stepFilter) (stepFilter, error) {
+	var err error
+	if v, ok := r.URL.Query()["name"]; ok {
+		f.Name = strings.TrimSpace(v[0])
+	}
+	if f.MinDuration, err = durationParam(r, "minDuration", f.MinDuration); err != nil {
+		return f, err
+	}
+	if v, ok := r.URL.Query()["tag"]; ok {
+		f.Tag = strings.TrimSpace(v[0])
+	}
+	return f, nil
+}
+
+// matches reports whether the step matches every criterion of the filter.
+func (f stepFilter) matches(e Events) bool {
+	if f.Name != "" && !strings.Contains(strings.ToLower(e.StartupStep.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if e.Duration() < f.MinDuration {
+		return false
+	}
+	if f.Tag != "" {
+		key, value, _ := strings.Cut(f.Tag, ":")
+		found := false
+		for _, tag := range e.StartupStep.Tags {
+			if tag.Key == key && strings.Contains(tag.Value, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filterNodes keeps the nodes matching the filter and the ancestors of
+// matching nodes, which are recorded as context. The nodes are visited
+// depth-first in post-order using an explicit stack, so children are filtered
+// before their parent.
+func filterNodes(nodes []*Node, f stepFilter, context map[*Node]bool) []*Node {
+	kept := make(map[*Node]bool)
+	keep := func(nodes []*Node) []*Node {
+		var out []*Node
+		for _, n := range nodes {
+			if kept[n] {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+
+	type frame struct {
+		node    *Node
+		visited bool
+	}
+	stack := make([]frame, 0, len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		stack = append(stack, frame{node: nodes[i]})
+	}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n := top.node
+		if !top.visited {
+			stack = append(stack, frame{node: n, visited: true})
+			for i := len(n.Children) - 1; i >= 0; i-- {
+				stack = append(stack, frame{node: n.Children[i]})
+			}
+			continue
+		}
+		n.Children = keep(n.Children)
+		switch {
+		case f.matches(n.Event):
+			kept[n] = true
+		case len(n.Children) > 0:
+			context[n] = true
+			kept[n] = true
+		}
+	}
+	return keep(nodes)
+}
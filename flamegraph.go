@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+)
+
+// flame graph layout configs.
+const (
+	flameWidth     = 1200
+	flameRowHeight = 18
+	flameMinWidth  = 0.5 // frames narrower than it, in pixels, are dropped.
+	flameMinLabel  = 60  // frames narrower than it, in pixels, are not labeled.
+)
+
+// FlameFrame represents a step in the flame graph. X and Width are in pixels.
+type FlameFrame struct {
+	Event Events
+	Depth int
+	X     float64
+	Width float64
+}
+
+// Y returns the frame top position in pixels.
+func (f FlameFrame) Y() int {
+	return f.Depth * flameRowHeight
+}
+
+// Labeled reports whether the frame is wide enough for its label.
+func (f FlameFrame) Labeled() bool {
+	return f.Width >= flameMinLabel
+}
+
+// flameFrames lays out the step tree as a flame graph: children are stacked
+// under their parent, side by side, each as wide as its share of the
+// startup time. Children taking longer than their parent are scaled to fit it.
+func flameFrames(t Timeline) []FlameFrame {
+	tree := buildTree(t)
+
+	// scale to the startup time or the sum of the top-level steps, if longer.
+	var total time.Duration
+	for _, n := range tree.Roots {
+		total += n.Event.Duration()
+	}
+	if t.Duration() > total {
+		total = t.Duration()
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	// place children from the left edge of their parent; parents are visited
+	// first.
+	var frames []FlameFrame
+	type place struct{ x, width, next, scale float64 }
+	places := make(map[*Node]*place)
+	rootX := 0.0
+	tree.Walk(func(n *Node) bool {
+		width := flameWidth * float64(n.Event.Duration()) / float64(total)
+		var x float64
+		if p := places[n.Parent]; n.Parent != nil && p != nil {
+			width *= p.scale
+			x, p.next = p.next, p.next+width
+		} else {
+			x, rootX = rootX, rootX+width
+		}
+		if width < flameMinWidth {
+			return false
+		}
+
+		// scale children down when they don't fit.
+		var children time.Duration
+		for _, c := range n.Children {
+			children += c.Event.Duration()
+		}
+		scale := 1.0
+		if children > n.Event.Duration() {
+			scale = float64(n.Event.Duration()) / float64(children)
+		}
+		places[n] = &place{x: x, width: width, next: x, scale: scale}
+		frames = append(frames, FlameFrame{Event: n.Event, Depth: n.Depth, X: x, Width: width})
+		return true
+	})
+	return frames
+}
+
+func handleFlamegraph(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// render page.
+	page, err := renderFlamegraphPage(report)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// set html content type.
+	setWarningHeaders(w, report)
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// renderFlamegraphPage renders the flame graph page.
+func renderFlamegraphPage(report *ServedReport) ([]byte, error) {
+	// set funcs.
+	funcs := template.FuncMap{
+		"fill": func(d time.Duration) string {
+			return svgColors[classBasedOnDuration(d)]
+		},
+	}
+
+	// load template.
+	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/flamegraph.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// layout frames.
+	frames := flameFrames(report.Timeline)
+	depth := 0
+	for _, f := range frames {
+		if f.Depth+1 > depth {
+			depth = f.Depth + 1
+		}
+	}
+	view := struct {
+		*ServedReport
+		Frames []FlameFrame
+		Width  int
+		Height int
+	}{report, frames, flameWidth, depth * flameRowHeight}
+
+	// render template.
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "flamegraph.html", view); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
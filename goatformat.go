@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// goat normalized report format; the version is bumped on incompatible
+// changes, importers reject versions newer than theirs.
+const (
+	goatFormat        = "goat"
+	goatFormatVersion = 1
+)
+
+// GoatReport represents a report in goat's normalized format: the parsed
+// steps with their place in the tree, fingerprints and analysis. It imports
+// back into the same report, so tools can skip parsing actuator json.
+type GoatReport struct {
+	Format            string      `json:"format"`
+	Version           int         `json:"version"`
+	ReportID          string      `json:"reportId"`
+	SpringBootVersion string      `json:"springBootVersion"`
+	StartTime         time.Time   `json:"startTime"`
+	StartupTimeMs     float64     `json:"startupTimeMs"`
+	Truncation        *Truncation `json:"truncation,omitempty"`
+
+	// Steps are in report order; Depth is their level in the repaired tree.
+	Steps    []GoatStep `json:"steps"`
+	Analysis Analysis   `json:"analysis"`
+}
+
+// GoatStep represents a step of a normalized report.
+type GoatStep struct {
+	ID          int       `json:"id"`
+	ParentID    *int      `json:"parentId,omitempty"`
+	Name        string    `json:"name"`
+	Tags        []Tags    `json:"tags"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	Depth       int       `json:"depth"`
+	Fingerprint string    `json:"fingerprint"`
+	DurationMs  float64   `json:"durationMs"`
+	SelfTimeMs  float64   `json:"selfTimeMs"`
+}
+
+// goatReport normalizes the report.
+func goatReport(ctx context.Context, report *StartupReport) (GoatReport, error) {
+	a, err := analyze(ctx, report)
+	if err != nil {
+		return GoatReport{}, err
+	}
+	g := GoatReport{
+		Format:            goatFormat,
+		Version:           goatFormatVersion,
+		ReportID:          report.ID,
+		SpringBootVersion: report.SpringBootVersion,
+		StartTime:         report.Timeline.StartTime,
+		StartupTimeMs:     millis(report.Timeline.Duration()),
+		Truncation:        report.Truncation,
+		Steps:             make([]GoatStep, len(report.Timeline.Events)),
+		Analysis:          a,
+	}
+	buildTree(report.Timeline).Walk(func(n *Node) bool {
+		e := n.Event
+		g.Steps[n.Index] = GoatStep{
+			ID:          e.StartupStep.ID,
+			ParentID:    e.StartupStep.ParentID,
+			Name:        e.StartupStep.Name,
+			Tags:        e.StartupStep.Tags,
+			StartTime:   e.StartTime,
+			EndTime:     e.EndTime,
+			Depth:       n.Depth,
+			Fingerprint: stepFingerprint(e.StartupStep),
+			DurationMs:  millis(e.Duration()),
+			SelfTimeMs:  millis(n.SelfTime),
+		}
+		return true
+	})
+	return g, nil
+}
+
+// isGoatReport reports whether the content is a normalized goat report. Only
+// the first field is read, which is the format in the reports goat writes.
+func isGoatReport(content []byte) bool {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return false
+	}
+	if key, err := dec.Token(); err != nil || key != "format" {
+		return false
+	}
+	var format string
+	return dec.Decode(&format) == nil && format == goatFormat
+}
+
+// importGoatReport rebuilds the report of a normalized goat report; the
+// derived fields are recomputed, not trusted. Unknown fields are rejected in
+// strict mode.
+func importGoatReport(content []byte) (*StartupReport, error) {
+	var g GoatReport
+	if err := unmarshalJSON(content, &g); err != nil {
+		return nil, err
+	}
+	if g.Version < 1 || g.Version > goatFormatVersion {
+		return nil, fmt.Errorf("unsupported goat report version %d, this goat reads up to version %d", g.Version, goatFormatVersion)
+	}
+	report := &StartupReport{
+		SpringBootVersion: g.SpringBootVersion,
+		Timeline:          Timeline{StartTime: g.StartTime, Events: make([]Events, len(g.Steps))},
+		ID:                g.ReportID,
+		Truncation:        g.Truncation,
+	}
+	for i, s := range g.Steps {
+		report.Timeline.Events[i] = Events{
+			StartupStep: StartupStep{Name: s.Name, ID: s.ID, ParentID: s.ParentID, Tags: s.Tags},
+			StartTime:   s.StartTime,
+			EndTime:     s.EndTime,
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// HistoryEntry represents a report recorded in the history.
+type HistoryEntry struct {
+	ID                int64             `json:"id"`
+	ReportID          string            `json:"reportId"`
+	RecordedAt        time.Time         `json:"recordedAt"` // in UTC.
+	Source            string            `json:"source"`
+	Labels            map[string]string `json:"labels"`
+	SpringBootVersion string            `json:"springBootVersion"`
+	StartupTimeMs     float64           `json:"startupTimeMs"`
+}
+
+// historyStore persists the reports loaded by goat.
+type historyStore interface {
+	// Save records the report loaded from the source, unless it was already
+	// recorded for it, and returns the id it is recorded with.
+	Save(ctx context.Context, report *StartupReport, source string, labels map[string]string) (int64, error)
+
+	// List returns the newest recorded reports first, of the source only
+	// unless empty.
+	List(ctx context.Context, limit int, source string) ([]HistoryEntry, error)
+
+	// Report returns the recorded report with the id.
+	Report(ctx context.Context, id int64) (*StartupReport, error)
+
+	// Count returns the number of recorded reports.
+	Count(ctx context.Context) (int, error)
+
+	Close() error
+}
+
+// errNotRecorded is returned for reports missing from the history.
+var errNotRecorded = errors.New("report not recorded")
+
+var (
+	// history records the loaded reports; nil when disabled.
+	history historyStore
+
+	// historyLabels are the labels recorded with the reports.
+	historyLabels map[string]string
+)
+
+// parseLabels parses comma separated key=value labels.
+func parseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, label := range strings.Split(s, ",") {
+		if strings.TrimSpace(label) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(label, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", label)
+		}
+		labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return labels, nil
+}
+
+// recordHistory records the report in the history, when enabled.
+func recordHistory(ctx context.Context, report *StartupReport, source string) error {
+	if history == nil {
+		return nil
+	}
+	_, err := history.Save(ctx, report, source, historyLabels)
+	return err
+}
+
+// sqliteHistory is a history store backed by a sqlite database.
+type sqliteHistory struct {
+	db *sql.DB
+}
+
+// openSQLiteHistory opens the sqlite history database, creating it if needed.
+func openSQLiteHistory(path string) (*sqliteHistory, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id TEXT NOT NULL,
+		recorded_at TEXT NOT NULL,
+		source TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		spring_boot_version TEXT NOT NULL,
+		startup_time_ms REAL NOT NULL,
+		content BLOB NOT NULL,
+		UNIQUE (report_id, source)
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteHistory{db: db}, nil
+}
+
+// Save implements historyStore.
+func (h *sqliteHistory) Save(ctx context.Context, report *StartupReport, source string, labels map[string]string) (int64, error) {
+	content, err := json.Marshal(report)
+	if err != nil {
+		return 0, err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	encodedLabels, err := json.Marshal(labels)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = h.db.ExecContext(ctx, `INSERT INTO reports (report_id, recorded_at, source, labels, spring_boot_version, startup_time_ms, content)
+		VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (report_id, source) DO NOTHING`,
+		report.ID, time.Now().UTC().Format(time.RFC3339Nano), source, string(encodedLabels), report.SpringBootVersion, millis(report.Timeline.Duration()), content); err != nil {
+		return 0, err
+	}
+	var id int64
+	err = h.db.QueryRowContext(ctx, `SELECT id FROM reports WHERE report_id = ? AND source = ?`, report.ID, source).Scan(&id)
+	return id, err
+}
+
+// List implements historyStore.
+func (h *sqliteHistory) List(ctx context.Context, limit int, source string) ([]HistoryEntry, error) {
+	// ids grow in recording order; recorded_at, trimmed RFC3339Nano text,
+	// doesn't sort within a second.
+	rows, err := h.db.QueryContext(ctx, `SELECT id, report_id, recorded_at, source, labels, spring_boot_version, startup_time_ms
+		FROM reports WHERE ? = '' OR source = ? ORDER BY id DESC LIMIT ?`, source, source, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		var e HistoryEntry
+		var recordedAt, labels string
+		if err := rows.Scan(&e.ID, &e.ReportID, &recordedAt, &e.Source, &labels, &e.SpringBootVersion, &e.StartupTimeMs); err != nil {
+			return nil, err
+		}
+		if e.RecordedAt, err = time.Parse(time.RFC3339Nano, recordedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(labels), &e.Labels); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Report implements historyStore.
+func (h *sqliteHistory) Report(ctx context.Context, id int64) (*StartupReport, error) {
+	var reportID string
+	var content []byte
+	err := h.db.QueryRowContext(ctx, `SELECT report_id, content FROM reports WHERE id = ?`, id).Scan(&reportID, &content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotRecorded
+	}
+	if err != nil {
+		return nil, err
+	}
+	report := &StartupReport{}
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, err
+	}
+	report.ID = reportID
+	return report, nil
+}
+
+// Count implements historyStore.
+func (h *sqliteHistory) Count(ctx context.Context) (int, error) {
+	var n int
+	err := h.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports`).Scan(&n)
+	return n, err
+}
+
+// Close implements historyStore.
+func (h *sqliteHistory) Close() error {
+	return h.db.Close()
+}
+
+// historyLimit returns the ?limit of history entries, 100 by default.
+func historyLimit(r *http.Request) (int, error) {
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return 0, errors.New("invalid limit")
+		}
+	}
+	return limit, nil
+}
+
+func handleHistory(w http.ResponseWriter, r *http.Request) {
+	// get limit.
+	limit, err := historyLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// list reports.
+	entries, err := history.List(r.Context(), limit, "")
+	if err != nil {
+		log.Printf("failed to list history: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, entries)
+}
+
+func handleHistoryReport(w http.ResponseWriter, r *http.Request) {
+	report, ok := loadHistoryReport(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, report)
+}
+
+func handleHistoryPage(w http.ResponseWriter, r *http.Request) {
+	// get limit.
+	limit, err := historyLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// list reports.
+	entries, err := history.List(r.Context(), limit, "")
+	if err != nil {
+		log.Printf("failed to list history: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// render page.
+	page, err := renderHistoryPage(entries)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// renderHistoryPage renders the history page of the entries, newest first.
+func renderHistoryPage(entries []HistoryEntry) ([]byte, error) {
+	// load template.
+	funcs := template.FuncMap{
+		"classBasedOnDuration": classBasedOnDuration,
+		"ms": func(v float64) time.Duration {
+			return time.Duration(v * float64(time.Millisecond))
+		},
+	}
+	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/history.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// render template.
+	var buf bytes.Buffer
+	data := struct {
+		Entries []HistoryEntry
+		Trend   string
+	}{entries, trendPoints(entries, 600, 100)}
+	if err := tpl.ExecuteTemplate(&buf, "history.html", data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func handleHistoryReportPage(w http.ResponseWriter, r *http.Request) {
+	report, ok := loadHistoryReport(w, r)
+	if !ok {
+		return
+	}
+
+	// render the report page, without links to the served report.
+	page, err := renderReportPage(r.Context(), &ServedReport{StartupReport: report}, pageOptions{Sort: defaultPageOptions.Sort, Standalone: true})
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// loadHistoryReport loads the recorded report of the {id} path value, or
+// replies with the error.
+func loadHistoryReport(w http.ResponseWriter, r *http.Request) (*StartupReport, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid report id", http.StatusBadRequest)
+		return nil, false
+	}
+	report, err := history.Report(r.Context(), id)
+	if errors.Is(err, errNotRecorded) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("failed to load recorded report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return report, true
+}
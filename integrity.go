@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// integrityTolerance absorbs the rounding of report timestamps before events
+// are flagged as extending beyond their parents or the timeline.
+const integrityTolerance = time.Millisecond
+
+// IntegrityIssue represents a timeline data-quality problem, which makes
+// durations unreliable rather than slow.
+type IntegrityIssue struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	StepIDs []int  `json:"stepIds"`
+}
+
+// checkTimelineIntegrity returns the data-quality problems of the timeline:
+// negative durations, events ending before the timeline start, events
+// starting before it, which points at clock skew, and children extending
+// beyond their parents.
+func checkTimelineIntegrity(t Timeline) []IntegrityIssue {
+	var negative, beforeStart, skewed, outside []int
+	buildTree(t).Walk(func(n *Node) bool {
+		e, id := n.Event, n.Event.StartupStep.ID
+		if e.EndTime.Before(e.StartTime) {
+			negative = append(negative, id)
+		}
+		switch {
+		case e.EndTime.Before(t.StartTime.Add(-integrityTolerance)):
+			beforeStart = append(beforeStart, id)
+		case e.StartTime.Before(t.StartTime.Add(-integrityTolerance)):
+			skewed = append(skewed, id)
+		}
+		if p := n.Parent; p != nil && (e.StartTime.Before(p.Event.StartTime.Add(-integrityTolerance)) || e.EndTime.After(p.Event.EndTime.Add(integrityTolerance))) {
+			outside = append(outside, id)
+		}
+		return true
+	})
+
+	var issues []IntegrityIssue
+	for _, issue := range []struct {
+		kind, message string
+		ids           []int
+	}{
+		{"negative-duration", "%d steps end before they start", negative},
+		{"before-timeline-start", "%d steps end before the timeline starts", beforeStart},
+		{"clock-skew", "%d steps start before the timeline starts; the clock likely moved during startup, e.g. an ntp adjustment", skewed},
+		{"child-outside-parent", "%d steps extend beyond their parent step", outside},
+	} {
+		if len(issue.ids) > 0 {
+			issues = append(issues, IntegrityIssue{Kind: issue.kind, Message: fmt.Sprintf(issue.message, len(issue.ids)), StepIDs: issue.ids})
+		}
+	}
+	return issues
+}
+
+func checkIntegrity(t Timeline) []Finding {
+	var findings []Finding
+	for _, issue := range checkTimelineIntegrity(t) {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  issue.Message + "; durations involving them are unreliable.",
+			StepIDs:  issue.StepIDs,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// job states.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// maxFinishedJobs is the number of finished jobs kept for their results.
+const maxFinishedJobs = 100
+
+// Job represents a heavy analysis run in the background, polled at
+// /api/jobs/{id} until done.
+type Job struct {
+	ID         int64       `json:"id"`
+	Kind       string      `json:"kind"`
+	State      string      `json:"state"`
+	Done       int         `json:"done"`
+	Total      int         `json:"total"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Result     interface{} `json:"result,omitempty"`
+
+	run jobRun
+}
+
+// jobRun runs a job, calling progress as it goes.
+type jobRun func(ctx context.Context, progress func(done, total int)) (interface{}, error)
+
+// jobKinds create the runs of the kinds of jobs from the request parameters.
+var jobKinds = map[string]func(r *http.Request) (jobRun, error){
+	"trends": func(r *http.Request) (jobRun, error) {
+		q, err := parseTrendsQuery(r)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+			return trends(ctx, q, progress)
+		}, nil
+	},
+	"leaderboard": func(r *http.Request) (jobRun, error) {
+		limit, err := historyLimit(r)
+		if err != nil {
+			return nil, err
+		}
+		top, ok := leaderboardTop(r)
+		if !ok {
+			return nil, errors.New("invalid top")
+		}
+		return func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+			return leaderboard(ctx, limit, top, progress)
+		}, nil
+	},
+	"history-stats": func(r *http.Request) (jobRun, error) {
+		limit, err := historyLimit(r)
+		if err != nil {
+			return nil, err
+		}
+		source := r.URL.Query().Get("source")
+		return func(ctx context.Context, progress func(done, total int)) (interface{}, error) {
+			_, reports, err := historyReports(ctx, limit, source, progress)
+			if err != nil {
+				return nil, err
+			}
+			if len(reports) == 0 {
+				return nil, errors.New("no report recorded")
+			}
+			return dirStats(reports, 5, 10), nil
+		}, nil
+	},
+}
+
+// jobQueue runs the jobs one at a time, in submission order.
+type jobQueue struct {
+	mu     sync.Mutex
+	nextID int64
+	jobs   map[int64]*Job
+	order  []int64 // by id.
+	queue  chan *Job
+	start  sync.Once
+}
+
+// jobs is the queue of the heavy analyses.
+var jobs = &jobQueue{jobs: make(map[int64]*Job), queue: make(chan *Job, 100)}
+
+// submit queues the job, failing when the queue is full.
+func (q *jobQueue) submit(kind string, run jobRun) (Job, error) {
+	q.start.Do(func() {
+		go q.work()
+	})
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.nextID++
+	j := &Job{ID: q.nextID, Kind: kind, State: JobQueued, CreatedAt: time.Now().UTC(), run: run}
+	select {
+	case q.queue <- j:
+	default:
+		return Job{}, errors.New("too many queued jobs")
+	}
+	q.jobs[j.ID] = j
+	q.order = append(q.order, j.ID)
+	q.evict()
+	return *j, nil
+}
+
+// evict drops the oldest finished jobs beyond maxFinishedJobs. It must be
+// called with q.mu locked.
+func (q *jobQueue) evict() {
+	finished := 0
+	for _, id := range q.order {
+		if s := q.jobs[id].State; s == JobDone || s == JobFailed {
+			finished++
+		}
+	}
+	kept := q.order[:0]
+	for _, id := range q.order {
+		if s := q.jobs[id].State; finished > maxFinishedJobs && (s == JobDone || s == JobFailed) {
+			delete(q.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	q.order = kept
+}
+
+// work runs the queued jobs.
+func (q *jobQueue) work() {
+	for j := range q.queue {
+		q.update(j, func(j *Job) {
+			now := time.Now().UTC()
+			j.State, j.StartedAt = JobRunning, &now
+		})
+		result, err := j.run(context.Background(), func(done, total int) {
+			q.update(j, func(j *Job) {
+				j.Done, j.Total = done, total
+			})
+		})
+		q.update(j, func(j *Job) {
+			now := time.Now().UTC()
+			j.FinishedAt, j.run = &now, nil
+			if err != nil {
+				log.Printf("failed to run %s job %d: %s", j.Kind, j.ID, err)
+				j.State, j.Error = JobFailed, err.Error()
+				return
+			}
+			j.State, j.Result = JobDone, result
+		})
+	}
+}
+
+// update changes the job with q.mu locked.
+func (q *jobQueue) update(j *Job, fn func(j *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fn(j)
+}
+
+// get returns a copy of the job with the id.
+func (q *jobQueue) get(id int64) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// list returns copies of the jobs, newest first and without their results.
+func (q *jobQueue) list() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := []Job{}
+	for i := len(q.order) - 1; i >= 0; i-- {
+		j := *q.jobs[q.order[i]]
+		j.Result = nil
+		list = append(list, j)
+	}
+	return list
+}
+
+func handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	// get job kind.
+	kind := r.URL.Query().Get("kind")
+	newRun, ok := jobKinds[kind]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown job kind %q, expected one of %v", kind, sortedKeys(jobKinds)), http.StatusBadRequest)
+		return
+	}
+	run, err := newRun(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// submit job.
+	j, err := jobs.submit(kind, run)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("jobs/%d", j.ID))
+	w.WriteHeader(http.StatusAccepted)
+	if err := encodeJSON(w, j); err != nil {
+		log.Printf("failed to write json: %s", err)
+	}
+}
+
+func handleJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, jobs.list())
+}
+
+func handleJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+	j, ok := jobs.get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, j)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// isJPAStep reports whether the step is about an entity manager factory or
+// another Hibernate bean.
+func isJPAStep(s StartupStep) bool {
+	class := strings.ToLower(s.Tag("beanType") + " " + s.Tag("beanName"))
+	return strings.Contains(class, "entitymanagerfactory") || strings.Contains(class, "hibernate")
+}
+
+// isRepositoryStep reports whether the step scans or initializes Spring Data
+// repositories.
+func isRepositoryStep(s StartupStep) bool {
+	return strings.HasPrefix(s.Name, "spring.data.repository.")
+}
+
+func checkJPAInitialization(t Timeline) []Finding {
+	var findings []Finding
+
+	// entity manager factories; nested ones are part of the outermost.
+	buildTree(t).Walk(func(n *Node) bool {
+		step := n.Event.StartupStep
+		if !isJPAStep(step) {
+			return true
+		}
+		if n.Event.Duration() < 200*time.Millisecond {
+			return false
+		}
+
+		// break down the cost; the self time is where hibernate builds its
+		// metadata and validates the schema, which the report doesn't trace.
+		var nested []string
+		for _, c := range n.Children {
+			nested = append(nested, fmt.Sprintf("%s %s", c.Event.StartupStep.Key(), formatDuration(c.Event.Duration())))
+		}
+		message := fmt.Sprintf("%s took %s: %s in hibernate bootstrap (metadata building, schema validation)", step.Key(), formatDuration(n.Event.Duration()), formatDuration(n.SelfTime))
+		if len(nested) > 0 {
+			message += fmt.Sprintf(" and %s in nested beans (%s)", formatDuration(n.Event.Duration()-n.SelfTime), strings.Join(nested, ", "))
+		}
+		message += "; consider spring.jpa.hibernate.ddl-auto=none to skip schema validation, hibernate.boot.allow_jdbc_metadata_access=false to skip reading database metadata, and spring.data.jpa.repositories.bootstrap-mode=deferred to bootstrap in the background."
+
+		severity := SeverityInfo
+		if n.SelfTime >= time.Second {
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Severity:   severity,
+			Message:    message,
+			StepIDs:    []int{step.ID},
+			DurationMs: millis(n.Event.Duration()),
+		})
+		return false
+	})
+
+	// repositories.
+	var ids []int
+	var total time.Duration
+	for _, e := range t.Events {
+		if isRepositoryStep(e.StartupStep) {
+			ids = append(ids, e.StartupStep.ID)
+			total += e.Duration()
+		}
+	}
+	if total >= 200*time.Millisecond {
+		findings = append(findings, Finding{
+			Severity:   SeverityInfo,
+			Message:    fmt.Sprintf("spring data repositories took %s to scan and initialize; consider spring.data.jpa.repositories.bootstrap-mode=lazy.", formatDuration(total)),
+			StepIDs:    ids,
+			DurationMs: millis(total),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// LeaderboardEntry represents a step fingerprint ranked by its cost across
+// the applications: the sum of its self time over their latest reports, so
+// steps slowing many applications rank above ones slowing a single one.
+type LeaderboardEntry struct {
+	Fingerprint string   `json:"fingerprint"`
+	Apps        []string `json:"apps"`
+	TotalMs     float64  `json:"totalMs"`
+	AvgMs       float64  `json:"avgMs"`
+	MaxMs       float64  `json:"maxMs"`
+}
+
+// Leaderboard represents the most expensive step fingerprints of the fleet.
+type Leaderboard struct {
+	Apps    int                `json:"apps"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// stepFingerprint identifies a step across applications: by bean type when
+// tagged, since bean names are application specific, and by name otherwise.
+func stepFingerprint(s StartupStep) string {
+	if beanType := s.Tag("beanType"); beanType != "" {
+		return s.Name + " [" + beanType + "]"
+	}
+	return s.Name
+}
+
+// historyApp returns the application of the recorded report: its app label,
+// or else its source.
+func historyApp(e HistoryEntry) string {
+	if app := e.Labels["app"]; app != "" {
+		return app
+	}
+	return e.Source
+}
+
+// leaderboard ranks the step fingerprints by their self time over the latest
+// recorded report of every application, among the limit newest reports.
+// progress, if not nil, is called after each report.
+func leaderboard(ctx context.Context, limit, top int, progress func(done, total int)) (Leaderboard, error) {
+	// latest report per application.
+	entries, err := history.List(ctx, limit, "")
+	if err != nil {
+		return Leaderboard{}, err
+	}
+	var latest []HistoryEntry
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if app := historyApp(e); !seen[app] {
+			seen[app] = true
+			latest = append(latest, e)
+		}
+	}
+
+	// self time by fingerprint and application.
+	costs := make(map[string]map[string]time.Duration)
+	for i, e := range latest {
+		report, err := history.Report(ctx, e.ID)
+		if err != nil {
+			return Leaderboard{}, err
+		}
+		buildTree(report.Timeline).Walk(func(n *Node) bool {
+			fp := stepFingerprint(n.Event.StartupStep)
+			if costs[fp] == nil {
+				costs[fp] = make(map[string]time.Duration)
+			}
+			costs[fp][historyApp(e)] += n.SelfTime
+			return true
+		})
+		if progress != nil {
+			progress(i+1, len(latest))
+		}
+	}
+
+	// rank fingerprints.
+	board := Leaderboard{Apps: len(latest), Entries: []LeaderboardEntry{}}
+	for _, fp := range sortedKeys(costs) {
+		entry := LeaderboardEntry{Fingerprint: fp, Apps: sortedKeys(costs[fp])}
+		var total time.Duration
+		for _, d := range costs[fp] {
+			total += d
+			entry.MaxMs = max(entry.MaxMs, millis(d))
+		}
+		entry.TotalMs, entry.AvgMs = millis(total), millis(total/time.Duration(len(costs[fp])))
+		board.Entries = append(board.Entries, entry)
+	}
+	sort.SliceStable(board.Entries, func(i, j int) bool {
+		return board.Entries[i].TotalMs > board.Entries[j].TotalMs
+	})
+	if len(board.Entries) > top {
+		board.Entries = board.Entries[:top]
+	}
+	return board, nil
+}
+
+// leaderboardTop returns the ?top number of fingerprints ranked, 20 by
+// default.
+func leaderboardTop(r *http.Request) (int, bool) {
+	top := 20
+	if v := r.URL.Query().Get("top"); v != "" {
+		var err error
+		if top, err = strconv.Atoi(v); err != nil || top < 1 {
+			return 0, false
+		}
+	}
+	return top, true
+}
+
+// loadLeaderboard computes the leaderboard of the request, or replies with the
+// error.
+func loadLeaderboard(w http.ResponseWriter, r *http.Request) (Leaderboard, bool) {
+	limit, err := historyLimit(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return Leaderboard{}, false
+	}
+	top, ok := leaderboardTop(r)
+	if !ok {
+		http.Error(w, "invalid top", http.StatusBadRequest)
+		return Leaderboard{}, false
+	}
+	board, err := leaderboard(r.Context(), limit, top, nil)
+	if err != nil {
+		log.Printf("failed to compute leaderboard: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return Leaderboard{}, false
+	}
+	return board, true
+}
+
+func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	board, ok := loadLeaderboard(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, board)
+}
+
+func handleLeaderboardPage(w http.ResponseWriter, r *http.Request) {
+	board, ok := loadLeaderboard(w, r)
+	if !ok {
+		return
+	}
+	page, err := renderLeaderboardPage(board)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// renderLeaderboardPage renders the fleet leaderboard page.
+func renderLeaderboardPage(board Leaderboard) ([]byte, error) {
+	// load template.
+	funcs := template.FuncMap{
+		"classBasedOnDuration": classBasedOnDuration,
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"ms": func(v float64) time.Duration {
+			return time.Duration(v * float64(time.Millisecond))
+		},
+	}
+	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/leaderboard.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// render template.
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "leaderboard.html", board); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// maxReportSize caps the size in bytes of a report, after decompression.
+	// 0 means no limit.
+	maxReportSize int64 = 64 << 20
+
+	// processingTimeout caps the time spent parsing or analyzing a report. 0
+	// means no limit.
+	processingTimeout time.Duration
+)
+
+// reportTooLargeError is the error of a report above the size limit.
+type reportTooLargeError struct {
+	limit int64
+}
+
+func (e reportTooLargeError) Error() string {
+	return fmt.Sprintf("report is larger than the %d bytes limit, see -max-report-size", e.limit)
+}
+
+// bodyErrorStatus returns the status of a request whose body failed with
+// err: 413 when the body or its report went over their size limit, status
+// otherwise.
+func bodyErrorStatus(err error, status int) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || errors.As(err, &reportTooLargeError{}) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+// readReportContent reads the report content from r, failing once it grows
+// above the report size limit.
+func readReportContent(r io.Reader) ([]byte, error) {
+	if maxReportSize <= 0 {
+		return io.ReadAll(r)
+	}
+	content, err := io.ReadAll(io.LimitReader(r, maxReportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxReportSize {
+		return nil, reportTooLargeError{maxReportSize}
+	}
+	return content, nil
+}
+
+// withProcessingTimeout runs fn, failing if it takes longer than the
+// processing timeout or ctx is done, e.g. because the client went away. The
+// ctx of fn is then cancelled and its result dropped: event decoding with
+// -max-events stops at the next event and analyses at the next rule.
+// Uncapped decoding and a rule already running can't be interrupted and
+// finish in the background, bounded by the -max-report-size and -max-events
+// limits of the report.
+func withProcessingTimeout[T any](ctx context.Context, what string, fn func(ctx context.Context) (T, error)) (T, error) {
+	if processingTimeout <= 0 {
+		return fn(ctx)
+	}
+	parent := ctx
+	ctx, cancel := context.WithTimeout(parent, processingTimeout)
+	defer cancel()
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn(ctx)
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		if err := parent.Err(); err != nil {
+			return zero, err
+		}
+		return zero, fmt.Errorf("%s took longer than the %s limit, see -processing-timeout", what, processingTimeout)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// serverAddr is the address the ui listens on, overriding the port.
+	serverAddr string
+
+	// apiAddr is the address the api and metrics listen on, apart from the
+	// ui. empty serves everything on the ui address.
+	apiAddr string
+
+	// server timeouts; slow clients can't hold connections forever, and
+	// in-flight requests get shutdownTimeout to drain on SIGTERM.
+	readTimeout     = time.Minute
+	writeTimeout    = 2 * time.Minute
+	idleTimeout     = 2 * time.Minute
+	shutdownTimeout = 10 * time.Second
+)
+
+// readHeaderTimeout caps the time spent reading request headers.
+const readHeaderTimeout = 10 * time.Second
+
+// registerListenerFlags registers the server timeout flags on the flag set.
+func registerListenerFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&readTimeout, "read-timeout", readTimeout, "maximum time spent reading a request, body included; streams to /api/stream aren't bound by it. 0 means no limit.")
+	fs.DurationVar(&writeTimeout, "write-timeout", writeTimeout, "maximum time spent writing a response. 0 means no limit.")
+	fs.DurationVar(&idleTimeout, "idle-timeout", idleTimeout, "time idle keep-alive connections are kept open.")
+	fs.DurationVar(&shutdownTimeout, "shutdown-timeout", shutdownTimeout, "time in-flight requests are given to complete on SIGINT or SIGTERM.")
+}
+
+// pageAPIPaths are the api paths linked from the report page, which the ui
+// keeps serving when the api listens apart.
+var pageAPIPaths = []string{"/api/groups", "/api/steps/", "/api/updates"}
+
+// isAPIPath reports whether the path is a machine endpoint: the metrics or the
+// api, of the report or of one of several reports.
+func isAPIPath(path string) bool {
+	path = reportRelativePath(path)
+	return path == "/metrics" || strings.HasPrefix(path, "/api/")
+}
+
+// isPageAPIPath reports whether the api path is linked from the report page.
+func isPageAPIPath(path string) bool {
+	path = reportRelativePath(path)
+	for _, p := range pageAPIPaths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportRelativePath returns the path under /reports/{name}, or the path
+// itself.
+func reportRelativePath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/reports/")
+	if !ok {
+		return path
+	}
+	if _, p, found := strings.Cut(rest, "/"); found {
+		return "/" + p
+	}
+	return path
+}
+
+// listenerHandler restricts the handler to the ui or to the api paths.
+func listenerHandler(h http.Handler, api bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served := isAPIPath(r.URL.Path) == api || !api && isPageAPIPath(r.URL.Path)
+		if !served {
+			http.NotFound(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// servers returns the http servers of the configured listeners.
+func servers() []*http.Server {
+	addr := serverAddr
+	if addr == "" {
+		addr = ":" + serverPort
+	}
+	h := withAuth(routes())
+	newServer := func(addr string, h http.Handler) *http.Server {
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           h,
+			ReadHeaderTimeout: readHeaderTimeout,
+			ReadTimeout:       readTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+		}
+		// update streams never finish on their own.
+		server.RegisterOnShutdown(reportUpdates.close)
+		return server
+	}
+	if apiAddr == "" {
+		return []*http.Server{newServer(addr, h)}
+	}
+	return []*http.Server{
+		newServer(addr, listenerHandler(h, false)),
+		newServer(apiAddr, listenerHandler(h, true)),
+	}
+}
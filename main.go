@@ -1,84 +1,223 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/corabank/goat/pkg/startup"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ----------------------------------------------------------------
 // Actuator stuff's
 // ----------------------------------------------------------------
 
-// StartupReport represents the spring actuator startup report.
-type StartupReport struct {
-	SpringBootVersion string   `json:"springBootVersion"`
-	Timeline          Timeline `json:"timeline"`
+// The report types live in the startup package, so other tools can parse
+// reports without goat.
+type (
+	StartupReport = startup.Report
+	Timeline      = startup.Timeline
+	Events        = startup.Event
+	StartupStep   = startup.Step
+	Tags          = startup.Tag
+	Truncation    = startup.Truncation
+	Milestones    = startup.Milestones
+)
+
+func unmarshalReport(ctx context.Context, reportPath string) (*StartupReport, error) {
+	// get report.
+	f, err := os.Open(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reportContent, err := readReportContent(f)
+	if err != nil {
+		return nil, err
+	}
+	return decodeReport(ctx, reportContent)
+}
+
+// strictParsing makes report parsing fail on unknown fields and structural
+// surprises instead of tolerating them.
+var strictParsing bool
+
+// registerParseFlags registers the report parsing flags on the flag set.
+func registerParseFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&strictParsing, "strict", false, "fail on unknown fields and structural surprises in reports, e.g. to catch actuator format changes in CI.")
+	fs.IntVar(&maxEvents, "max-events", 0, "maximum number of events loaded per report, keeping the top-level and slowest ones. 0 means no limit.")
+	fs.Int64Var(&maxReportSize, "max-report-size", maxReportSize, "maximum report size in bytes, after decompression. 0 means no limit.")
+	fs.DurationVar(&processingTimeout, "processing-timeout", 0, "maximum time spent parsing or analyzing a report. 0 means no limit.")
 }
 
-// Tags represents the springboot startup step tags.
-type Tags struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+func parseReport(ctx context.Context, reportContent []byte) (*StartupReport, error) {
+	return withProcessingTimeout(ctx, "parsing the report", func(ctx context.Context) (*StartupReport, error) {
+		return unmarshalReportContent(ctx, reportContent)
+	})
 }
 
-// StartupStep represents the springboot startup step.
-type StartupStep struct {
-	Name     string `json:"name"`
-	ID       int    `json:"id"`
-	ParentID int    `json:"parentId"`
-	Tags     []Tags `json:"tags"`
+func unmarshalReportContent(ctx context.Context, reportContent []byte) (*StartupReport, error) {
+	// unmarshal report.
+	start := time.Now()
+	report := &StartupReport{}
+	var err error
+	if isGoatReport(reportContent) {
+		// normalized reports keep the id of their actuator report, and go
+		// through the same limits.
+		report, err = importGoatReport(reportContent)
+		if err == nil && maxEvents > 0 {
+			capReport(report, maxEvents)
+		}
+		if err == nil && strictParsing {
+			err = validateReport(report)
+		}
+		if err != nil {
+			stats.observeParse(time.Since(start), "", err)
+			return nil, err
+		}
+		stats.observeParse(time.Since(start), report.ID, nil)
+		return report, nil
+	}
+	if maxEvents > 0 {
+		report, err = decodeCapped(ctx, reportContent, maxEvents)
+	} else {
+		err = unmarshalJSON(reportContent, report)
+	}
+	if err == nil && strictParsing {
+		err = validateReport(report)
+	}
+	if err != nil {
+		stats.observeParse(time.Since(start), "", err)
+		return nil, err
+	}
+	report.ID = startup.ContentID(reportContent)
+	stats.observeParse(time.Since(start), report.ID, nil)
+	return report, nil
 }
 
-// Events represents the springboot startup timeline events.
-type Events struct {
-	StartupStep StartupStep `json:"startupStep"`
-	StartTime   time.Time   `json:"startTime"`
-	EndTime     time.Time   `json:"endTime"`
+// unmarshalJSON unmarshals data into v, disallowing unknown fields and trailing
+// data in strict mode.
+func unmarshalJSON(data []byte, v interface{}) error {
+	if !strictParsing {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	return expectEnd(dec)
 }
 
-// Duration calculates the startup time of the event.
-func (e Events) Duration() time.Duration {
-	return e.EndTime.Sub(e.StartTime)
+// validateReport checks the report structure in strict mode.
+func validateReport(report *StartupReport) error {
+	var errs []error
+	if report.Timeline.StartTime.IsZero() {
+		errs = append(errs, errors.New("timeline.startTime is missing"))
+	}
+	for i, e := range report.Timeline.Events {
+		if e.StartupStep.Name == "" {
+			errs = append(errs, fmt.Errorf("timeline.events[%d].startupStep.name is missing", i))
+		}
+		if e.StartTime.IsZero() || e.EndTime.IsZero() {
+			errs = append(errs, fmt.Errorf("timeline.events[%d] (id %d) is missing startTime or endTime", i, e.StartupStep.ID))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// Timeline represents the springboot startup timeline.
-type Timeline struct {
-	StartTime time.Time `json:"startTime"`
-	Events    []Events  `json:"events"`
+var (
+	// fetchTimeout caps each attempt at fetching a report, 0 means no limit.
+	fetchTimeout = 10 * time.Second
+
+	// fetchRetries is the number of times a failed fetch is retried.
+	fetchRetries = 2
+
+	// fetchBackoff is the delay before the first retry, doubled on each retry.
+	fetchBackoff = 500 * time.Millisecond
+)
+
+// registerFetchFlags registers the report fetching flags on the flag set.
+func registerFetchFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&fetchTimeout, "fetch-timeout", fetchTimeout, "maximum time spent on each attempt at fetching a report from an actuator endpoint. 0 means no limit.")
+	fs.IntVar(&fetchRetries, "fetch-retries", fetchRetries, "number of times fetching a report is retried on network errors and 5xx or 429 responses.")
+	fs.DurationVar(&fetchBackoff, "fetch-backoff", fetchBackoff, "delay before the first fetch retry, doubled on each retry.")
 }
 
-// Duration calculates the timeline duration.
-func (t Timeline) Duration() time.Duration {
-	// get max endTime.
-	var max time.Time
-	for _, e := range t.Events {
-		if e.EndTime.After(max) {
-			max = e.EndTime
+// fetchReport gets the startup report from a running actuator endpoint. It uses
+// GET, which returns a snapshot without draining the actuator buffer. Network
+// errors and server errors are retried with an exponential backoff.
+func fetchReport(ctx context.Context, url string) (report *StartupReport, err error) {
+	ctx, span := tracer.Start(ctx, "report.fetch", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("url.full", url)))
+	defer func() { endSpan(span, err) }()
+
+	backoff := fetchBackoff
+	for attempt := 0; ; attempt++ {
+		reportContent, retryable, err := fetchReportContent(ctx, url)
+		if err == nil {
+			return decodeReport(ctx, reportContent)
+		}
+		if !retryable || attempt == fetchRetries {
+			if attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return nil, err
+		}
+
+		// wait before retrying.
+		log.Printf("failed to fetch report, retrying in %s: %s", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
 		}
+		backoff *= 2
 	}
-	return max.Sub(t.StartTime)
 }
 
-func unmarshalReport(reportPath string) (*StartupReport, error) {
-	// get report.
-	reportContent, err := ioutil.ReadFile(reportPath)
-	if err != nil {
-		return nil, err
+// fetchReportContent makes one attempt at getting the report content, and
+// reports whether a failure is worth retrying.
+func fetchReportContent(ctx context.Context, url string) ([]byte, bool, error) {
+	if fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
 	}
 
-	// unmarshal report.
-	var report StartupReport
-	if err := json.Unmarshal(reportContent, &report); err != nil {
-		log.Fatalf("failed to unmarshal report: %s", err)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
 	}
-	return &report, nil
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil || errors.Is(ctx.Err(), context.DeadlineExceeded), err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retryable, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	reportContent, err := readReportContent(resp.Body)
+	return reportContent, false, err
 }
 
 // ----------------------------------------------------------------
@@ -92,31 +231,333 @@ var files embed.FS
 var (
 	serverPort string
 	reportPath string
+	summarizer Summarizer
+	telemetry  telemetryConfig
+
+	requestTimeout time.Duration
+	snapshotPages  bool
+	pidFile        string
 )
 
 func main() {
+	// run command; without one, goat serves.
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "analyze":
+			runAnalyze(args[1:])
+			return
+		case "bundle":
+			runBundle(args[1:])
+			return
+		case "check":
+			runCheck(args[1:])
+			return
+		case "comment":
+			runComment(args[1:])
+			return
+		case "config":
+			runConfig(args[1:])
+			return
+		case "diff":
+			runDiff(args[1:])
+			return
+		case "export":
+			runExport(args[1:])
+			return
+		case "help":
+			printUsage(os.Stdout)
+			return
+		case "mcp":
+			runMCP(args[1:])
+			return
+		case "serve":
+			args = args[1:]
+		case "stats":
+			runStats(args[1:])
+			return
+		case "service":
+			runService(args[1:])
+			return
+		case "top":
+			runTop(args[1:])
+			return
+		case "tui":
+			runTUI(args[1:])
+			return
+		default:
+			if !strings.HasPrefix(args[0], "-") {
+				fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", args[0])
+				printUsage(os.Stderr)
+				os.Exit(2)
+			}
+		}
+	}
+
 	// config.
-	loadConfigs()
+	loadConfigs(args)
+
+	// run as windows service.
+	if runAsService() {
+		return
+	}
 
-	// start server.
-	log.Fatal(http.ListenAndServe(":"+serverPort, routes()))
+	// start server until interrupted.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := serve(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// commands are the goat commands and what they do, printed by usage.
+var commands = [][2]string{
+	{"serve", "serve the report pages and api; the default command."},
+	{"analyze", "print the analysis of a report."},
+	{"check", "fail when a report exceeds its startup budgets."},
+	{"diff", "compare two live actuator startup endpoints."},
+	{"export", "write a report as html, csv, otlp or goat's normalized format."},
+	{"top", "print the slowest steps of a report."},
+	{"tui", "browse the steps of a report in the terminal."},
+	{"stats", "aggregate the reports of a directory."},
+	{"comment", "print a report comparison as a pull request comment."},
+	{"config", "validate the server configuration."},
+	{"bundle", "write a support bundle of the reports and configuration."},
+	{"mcp", "serve the reports to mcp clients over stdio."},
+	{"service", "install and control goat as a windows service."},
+	{"help", "print this help."},
 }
 
-func loadConfigs() {
+// printUsage prints the goat commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: goat <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s  %s\n", c[0], c[1])
+	}
+	fmt.Fprintf(w, "\nrun goat <command> -h for the flags of a command.\n")
+}
+
+// serve runs the server until ctx is done, then shuts it down gracefully.
+func serve(ctx context.Context) error {
+	// write pid file.
+	if pidFile != "" {
+		if err := writePIDFile(pidFile); err != nil {
+			return err
+		}
+		defer os.Remove(pidFile)
+	}
+
+	// setup telemetry.
+	shutdownTelemetry, err := telemetry.setup(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to setup telemetry: %w", err)
+	}
+	defer shutdownTelemetry(context.Background())
+
+	// pre-render page.
+	if snapshotPages {
+		refreshSnapshot(context.Background())
+	}
+
+	// start servers.
+	servers := servers()
+	errc := make(chan error, len(servers))
+	for _, server := range servers {
+		go func() {
+			errc <- serverTLS.listen(server)
+		}()
+	}
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		// drain in-flight requests.
+		log.Printf("shutting down, draining requests for up to %s", shutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		var err error
+		for _, server := range servers {
+			err = errors.Join(err, server.Shutdown(shutdownCtx))
+		}
+		return err
+	}
+}
+
+// serverFlags holds the server flags read once configs are loaded.
+type serverFlags struct {
+	configFile   string
+	reports      reportFlags
+	reportsDir   string
+	url          string
+	summarizer   summarizerConfig
+	pollInterval time.Duration
+	historyPath  string
+	labels       string
+	watch        bool
+	stream       bool
+	secrets      string
+	daemon       bool
+	logFile      string
+}
+
+// registerServerFlags registers the server flags on the flag set.
+func registerServerFlags(fs *flag.FlagSet) *serverFlags {
+	f := &serverFlags{}
+	fs.StringVar(&f.configFile, "config", "", "yaml or toml config file setting flags by name, e.g. port: 8080; flags given on the command line override it. goat.yaml, goat.yml or goat.toml in the working directory by default.")
+	fs.StringVar(&serverPort, "port", "8080", "server port.")
+	fs.StringVar(&serverAddr, "addr", "", "address the ui listens on, e.g. 0.0.0.0:8080. overrides port.")
+	fs.StringVar(&apiAddr, "api-addr", "", "address the api and metrics listen on apart from the ui, e.g. 127.0.0.1:9090. the ui then only serves the api paths linked from its pages.")
+	registerListenerFlags(fs)
+	fs.Var(&f.reports, "report", "spring actuator startup report: a json, gzip or zip file, a directory (newest report is used), an actuator url or - for stdin, which is also read when piped. repeat it, optionally as name=report, to serve several reports under /reports/{name}/. required unless url or reports-dir is set!")
+	fs.StringVar(&f.reportsDir, "reports-dir", "", "directory whose reports, files or directories, are all served under /reports/{name}/.")
+	fs.StringVar(&compareSource, "compare", "", "startup report the served one is compared against on the /compare page, e.g. the build of the next release. same formats as report.")
+	fs.StringVar(&f.url, "url", "", "actuator startup endpoint the report is fetched from, e.g. http://myapp:8080/actuator/startup.")
+	registerFetchFlags(fs)
+	f.summarizer.register(fs)
+	telemetry.register(fs)
+	auth.register(fs)
+	serverTLS.register(fs)
+	registerParseFlags(fs)
+	registerAnalysisFlags(fs)
+	fs.DurationVar(&requestTimeout, "request-timeout", 30*time.Second, "maximum time spent serving a request before it is cancelled. 0 disables the timeout.")
+	fs.IntVar(&defaultPageOptions.GroupAbove, "group-above", defaultPageOptions.GroupAbove, "group sibling steps sharing a name on the report page when there are more than this; ?groupAbove overrides it. 0 disables grouping.")
+	fs.IntVar(&defaultPageOptions.MaxDepth, "max-depth", 0, "levels of the step hierarchy rendered on the report page, deeper subtrees are fetched from /api/steps/{id}/subtree; ?depth overrides it. 0 renders all levels.")
+	fs.StringVar(&defaultPageOptions.Sort, "sort", defaultPageOptions.Sort, "order of child steps on the report page and in the tree apis: report, start or duration; ?sort overrides it. paginated pages, with ?page or ?size, sorted by duration rank all the steps instead of each level.")
+	fs.BoolVar(&checkTemplates, "check-templates", false, "render every page and run every analysis rule against a sample report on startup, exiting on errors instead of failing the first requests.")
+	registerThemeFlags(fs)
+	fs.DurationVar(&pageRefresh, "refresh", 0, "interval the report page reloads itself at, e.g. 30s for dashboards showing the latest report. 0 disables reloading, live reports reload every 5s.")
+	fs.BoolVar(&snapshotPages, "snapshot", false, "render the report page once per report change and serve the cached html.")
+	fs.DurationVar(&f.pollInterval, "poll-interval", 5*time.Second, "interval at which the report path or url is re-resolved and reloaded if it changed; open pages reload on new reports and requests in between reuse the polled copy of a url. 0 disables polling.")
+	fs.StringVar(&f.historyPath, "history", "", "sqlite database every loaded or uploaded report is recorded in, browsable at /history. disabled if empty.")
+	fs.StringVar(&f.labels, "labels", "", "comma separated key=value labels recorded with the reports in the history, e.g. env=prod,app=billing.")
+	fs.BoolVar(&f.watch, "watch", false, "reload the report as soon as its file changes, using file system notifications.")
+	fs.BoolVar(&f.stream, "stream", false, "build the report live from NDJSON events piped to stdin or posted to /api/stream.")
+	fs.StringVar(&f.secrets, "stream-secrets", "", "file of app=secret lines; reports posted to /api/stream or pushed to /api/reports must then be signed with the app secret, in the X-Goat-App and X-Goat-Signature: sha256=<hmac hex> headers. signed streams are buffered until the request ends.")
+	fs.BoolVar(&f.daemon, "daemon", false, "detach from the terminal and run in the background. not supported on windows, see goat service.")
+	fs.StringVar(&pidFile, "pid-file", "", "file the server pid is written to while running.")
+	fs.StringVar(&f.logFile, "log-file", "", "file logs are appended to instead of stderr.")
+	return f
+}
+
+func loadConfigs(args []string) {
 	// load configs.
-	flag.StringVar(&serverPort, "port", "8080", "server port.")
-	flag.StringVar(&reportPath, "report", "", "spring actuator startup report. required!")
-	flag.Parse()
+	f := registerServerFlags(flag.CommandLine)
+	flag.CommandLine.Usage = func() {
+		printUsage(flag.CommandLine.Output())
+		fmt.Fprintf(flag.CommandLine.Output(), "\nserve flags:\n")
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(args)
+	if err := applyConfigFile(flag.CommandLine, f.configFile); err != nil {
+		log.Fatal(err)
+	}
+	summarizer = f.summarizer.summarizer()
+
+	// redirect logs.
+	if f.logFile != "" {
+		file, err := os.OpenFile(f.logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("failed to open log file: %s", err)
+		}
+		log.SetOutput(file)
+	}
+
+	// detach.
+	if f.daemon {
+		daemonize()
+	}
 
-	// check report path.
+	// check configs; reports may show up once serving.
+	if errs := f.check(false); len(errs) > 0 {
+		for _, err := range errs {
+			log.Print(err)
+		}
+		log.Fatal("invalid configuration!")
+	}
+
+	// check templates and rules.
+	if checkTemplates {
+		if errs := selfCheck(context.Background()); len(errs) > 0 {
+			for _, err := range errs {
+				log.Print(err)
+			}
+			log.Fatal("self-check failed!")
+		}
+		log.Print("self-check passed.")
+	}
+
+	// open history.
+	historyLabels, _ = parseLabels(f.labels)
+	if f.historyPath != "" {
+		var err error
+		if history, err = openSQLiteHistory(f.historyPath); err != nil {
+			log.Fatalf("failed to open history: %s", err)
+		}
+	}
+
+	// load push secrets.
+	if f.secrets != "" {
+		streamSecrets, _ = loadSecrets(f.secrets)
+	}
+
+	// start stream.
+	if f.stream {
+		live = &liveReport{}
+		live.consumeStdin()
+		return
+	}
+
+	// set report path.
+	pollInterval = f.pollInterval
+	if len(f.reports) == 1 && f.reportsDir == "" {
+		// a single report is served at /, its name is unused.
+		_, reportPath = splitReportFlag(f.reports[0])
+	} else if len(f.reports) > 0 || f.reportsDir != "" {
+		setServedReports(f.reports, f.reportsDir)
+		if f.pollInterval > 0 {
+			go pollServedReport(f.pollInterval)
+		}
+		return
+	}
+	if f.url != "" {
+		reportPath = f.url
+	}
 	if reportPath == "" {
-		log.Fatal("spring actuator startup report is required!")
+		reportPath = stdinSource
+	}
+	if reportPath == stdinSource {
+		if _, err := readStdinReport(); err != nil {
+			log.Fatalf("failed to read report from stdin: %s", err)
+		}
+	}
+
+	lastGood.path = reportPath
+
+	// poll report.
+	if f.pollInterval > 0 {
+		go pollServedReport(f.pollInterval)
+	}
+	if f.watch {
+		if err := watchServedReport(); err != nil {
+			log.Fatalf("failed to watch report: %s", err)
+		}
 	}
 }
 
 func routes() *http.ServeMux {
+	if servingSeveral() {
+		return severalRoutes(reportRoutes())
+	}
+	return reportRoutes()
+}
+
+// reportRoutes returns the routes serving a report.
+func reportRoutes() *http.ServeMux {
 	// create server mux.
 	mux := http.NewServeMux()
+	handle := func(pattern string, h http.HandlerFunc) {
+		mux.Handle(pattern, instrument(pattern, withTimeout(h)))
+	}
 
 	// create file server.
 	directory, err := fs.Sub(files, "web/static")
@@ -126,52 +567,195 @@ func routes() *http.ServeMux {
 	fileServer := http.FileServer(http.FS(directory))
 
 	// server static files.
-	mux.Handle("/static/", http.StripPrefix("/static/", fileServer))
+	mux.Handle("GET /static/", instrument("GET /static/", http.StripPrefix("/static/", fileServer)))
+	mux.Handle("GET /static/theme.css", instrument("GET /static/theme.css", http.HandlerFunc(handleTheme)))
+
+	// handle timeline image and badge.
+	handle("GET /timeline.svg", handleTimelineSVG)
+	handle("GET /badge.svg", handleBadgeSVG)
+
+	// handle suggestions.
+	handle("GET /suggestions/autoconfigure-exclude", handleAutoConfigExclude)
+	handle("GET /suggestions/application.properties", handlePropertiesSnippet)
+
+	// handle metrics.
+	handle("GET /metrics", handleMetrics)
+
+	// handle api.
+	handle("GET /api/report", handleReportTree)
+	handle("GET /api/events", handleEvents)
+	handle("GET /api/summary", handleSummary)
+	handle("GET /api/analysis", handleAnalysis)
+	handle("GET /api/analysis/rules", handleRules)
+	handle("GET /api/steps/{id}", handleStep)
+	handle("GET /api/steps/{id}/subtree", handleSubtree)
+	handle("GET /api/groups", handleStepGroup)
+	handle("GET /api/beans/first", handleFirstBean)
+	handle("GET /api/step-catalog", handleStepCatalog)
+	handle("GET /api/treemap", handleTreemap)
+	if live == nil {
+		// update streams last as long as the page is open.
+		mux.Handle("GET /api/updates", instrument("GET /api/updates", http.HandlerFunc(handleUpdates)))
+	}
+	if live != nil {
+		// streams last as long as the client keeps sending.
+		mux.Handle("POST /api/stream", instrument("POST /api/stream", http.HandlerFunc(handleStream)))
+	}
+
+	// handle history.
+	if history != nil {
+		handle("GET /api/history", handleHistory)
+		handle("GET /api/history/{id}", handleHistoryReport)
+		handle("GET /api/trends", handleTrends)
+		handle("GET /api/leaderboard", handleLeaderboard)
+		handle("GET /leaderboard", handleLeaderboardPage)
+		handle("POST /api/reports", handlePushReport)
+
+		// heavy analyses of the history run as jobs.
+		handle("POST /api/jobs", handleSubmitJob)
+		handle("GET /api/jobs", handleJobs)
+		handle("GET /api/jobs/{id}", handleJob)
+		handle("GET /history", handleHistoryPage)
+		handle("GET /history/{id}", handleHistoryReportPage)
+	}
+
+	// handle uploads.
+	handle("GET /upload", handleUploadForm)
+	handle("POST /upload", handleUpload)
+
+	// handle flame graph.
+	handle("GET /flamegraph", handleFlamegraph)
+
+	// handle comparison.
+	if compareSource != "" {
+		handle("GET /compare", handleCompare)
+	}
 
 	// handle report.
-	mux.HandleFunc("/", handleReport)
+	handle("GET /{$}", handleReport)
 	return mux
 }
 
 func handleReport(w http.ResponseWriter, r *http.Request) {
 	// get report.
-	report, err := unmarshalReport(reportPath)
+	report, err := loadServedReport(r.Context())
 	if err != nil {
 		log.Printf("failed to unmarshal report: %s", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// get page options.
+	opts, err := parsePageOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// render page.
+	var page []byte
+	if snapshotPages && opts == defaultPageOptions {
+		page, err = snapshotReportPage(r.Context(), report)
+	} else {
+		page, err = renderReportPage(r.Context(), report, opts)
+	}
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// set html content type.
 	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
 
+// standaloneStyle returns the stylesheets inlined in standalone pages, so they
+// render without the server or a network.
+func standaloneStyle() ([]byte, error) {
+	var style []byte
+	for _, name := range []string{"web/static/base.css", themeFile(), "web/static/style.css"} {
+		content, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		style = append(append(style, content...), '\n')
+	}
+	return style, nil
+}
+
+// renderReportPage renders the report page.
+func renderReportPage(ctx context.Context, report *ServedReport, opts pageOptions) ([]byte, error) {
 	// set funcs.
 	funcs := template.FuncMap{
-		// classBasedOnDuration returns a css class based on the duration.
-		"classBasedOnDuration": func(t time.Duration) string {
-			if t > time.Second*5 {
-				return "badge-danger"
-			}
-			if t > time.Second*1 {
-				return "badge-warning"
-			}
-			return "badge-success"
+		"classBasedOnDuration": classBasedOnDuration,
+		"describe":             describeStep,
+		"indent": func(depth int) string {
+			return fmt.Sprintf("%dpx", 20*depth)
+		},
+		"repeat": func(n int) []struct{} {
+			return make([]struct{}, n)
 		},
 	}
 
 	// load template.
 	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/index.html")
 	if err != nil {
-		log.Printf("failed to load template: %s", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	if err := checkViewVersion(tpl); err != nil {
+		return nil, err
+	}
+
+	// summarize report.
+	view := newReportView(report, opts)
+	if opts.Standalone {
+		style, err := standaloneStyle()
+		if err != nil {
+			return nil, err
+		}
+		view.Style = template.CSS(style)
+	}
+	if summarizer != nil {
+		prompt, err := reportPrompt(ctx, report.StartupReport)
+		if err == nil {
+			view.Summary, err = summarizer.Summarize(ctx, prompt)
+		}
+		if err != nil {
+			log.Printf("failed to summarize report: %s", err)
+		}
 	}
 
 	// render template.
-	err = tpl.ExecuteTemplate(w, "index.html", report)
-	if err != nil {
-		log.Printf("failed to render template: %s", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "index.html", view); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// withTimeout cancels the request context and replies 503 when the handler
+// takes longer than the request timeout.
+func withTimeout(h http.Handler) http.Handler {
+	if requestTimeout <= 0 {
+		return h
+	}
+	return http.TimeoutHandler(h, requestTimeout, "request timed out")
+}
+
+// classBasedOnDuration returns a css class based on the duration.
+func classBasedOnDuration(t time.Duration) string {
+	return "badge-" + levelBasedOnDuration(t)
+}
+
+// levelBasedOnDuration returns how concerning the duration is: danger, warning
+// or success.
+func levelBasedOnDuration(t time.Duration) string {
+	if t > time.Second*5 {
+		return "danger"
+	}
+	if t > time.Second*1 {
+		return "warning"
 	}
+	return "success"
 }
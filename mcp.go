@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision implemented by goat.
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpRequest represents a JSON-RPC 2.0 request or notification.
+type mcpRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// mcpResponse represents a JSON-RPC 2.0 response.
+type mcpResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *mcpError       `json:"error,omitempty"`
+}
+
+// mcpError represents a JSON-RPC 2.0 error.
+type mcpError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC 2.0 error codes.
+const (
+	mcpParseError     = -32700
+	mcpMethodNotFound = -32601
+	mcpInvalidParams  = -32602
+)
+
+// mcpTool represents a tool exposed to MCP clients.
+type mcpTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	call        func(args map[string]interface{}) (string, error)
+}
+
+// mcpServer serves goat's analyses as MCP tools.
+type mcpServer struct {
+	reportPath string
+	tools      []mcpTool
+}
+
+// runMCP implements the mcp command: it serves MCP over stdin/stdout.
+func runMCP(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("mcp", flag.ExitOnError)
+	report := fs.String("report", "", "default spring actuator startup report used by the tools.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerAnalysisFlags(fs)
+	fs.Parse(args)
+
+	// serve.
+	s := newMCPServer(*report)
+	if err := s.serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("mcp server failed: %s", err)
+	}
+}
+
+func newMCPServer(reportPath string) *mcpServer {
+	s := &mcpServer{reportPath: reportPath}
+	reportProp := map[string]interface{}{
+		"type":        "string",
+		"description": "path to a spring actuator startup report; defaults to the report goat was started with.",
+	}
+	s.tools = []mcpTool{
+		{
+			Name:        "get_top_slow_steps",
+			Description: "List the slowest startup steps of a report.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"report": reportProp,
+					"limit":  map[string]interface{}{"type": "integer", "description": "number of steps to return, 10 by default."},
+				},
+			},
+			call: s.topSlowSteps,
+		},
+		{
+			Name:        "diff_runs",
+			Description: "Compare two startup reports and list regressions, improvements, new and removed steps.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"base":      map[string]interface{}{"type": "string", "description": "path to the base (old) report."},
+					"head":      map[string]interface{}{"type": "string", "description": "path to the head (new) report."},
+					"threshold": map[string]interface{}{"type": "string", "description": "minimum step delta to report, e.g. 100ms."},
+				},
+				"required": []string{"base", "head"},
+			},
+			call: s.diffRuns,
+		},
+		{
+			Name:        "get_recommendations",
+			Description: "Run goat's analyzers over a report and return the critical path, phases, findings and score.",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"report": reportProp},
+			},
+			call: s.recommendations,
+		},
+	}
+	return s
+}
+
+// serve reads newline delimited JSON-RPC messages from r and writes responses to w.
+func (s *mcpServer) serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req mcpRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			enc.Encode(mcpResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &mcpError{Code: mcpParseError, Message: err.Error()}})
+			continue
+		}
+
+		// notifications have no id and get no response.
+		result, rpcErr := s.handle(req)
+		if len(req.ID) == 0 {
+			continue
+		}
+		if err := enc.Encode(mcpResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *mcpServer) handle(req mcpRequest) (interface{}, *mcpError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]interface{}{"name": "goat", "version": goatVersion().Version},
+		}, nil
+	case "ping", "notifications/initialized":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &mcpError{Code: mcpInvalidParams, Message: err.Error()}
+		}
+		for _, t := range s.tools {
+			if t.Name == params.Name {
+				return toolResult(t.call(params.Arguments)), nil
+			}
+		}
+		return nil, &mcpError{Code: mcpInvalidParams, Message: fmt.Sprintf("unknown tool: %s", params.Name)}
+	default:
+		return nil, &mcpError{Code: mcpMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// toolResult wraps a tool output as an MCP tool call result. Tool failures are
+// reported to the client as results, not protocol errors.
+func toolResult(text string, err error) map[string]interface{} {
+	if err != nil {
+		text = err.Error()
+	}
+	return map[string]interface{}{
+		"content": []map[string]interface{}{{"type": "text", "text": text}},
+		"isError": err != nil,
+	}
+}
+
+// loadReport loads the report named by the given argument, or the default report.
+func (s *mcpServer) loadReport(args map[string]interface{}, name string) (*StartupReport, error) {
+	path, _ := args[name].(string)
+	if path == "" && name == "report" {
+		path = s.reportPath
+	}
+	if path == "" {
+		return nil, fmt.Errorf("%s report is required", name)
+	}
+	return loadReport(context.Background(), path)
+}
+
+func (s *mcpServer) topSlowSteps(args map[string]interface{}) (string, error) {
+	report, err := s.loadReport(args, "report")
+	if err != nil {
+		return "", err
+	}
+	limit := 10
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+
+	var steps []AnalysisStep
+	for _, e := range report.Timeline.Slowest(limit) {
+		steps = append(steps, analysisStep(e))
+	}
+	return marshalIndent(steps)
+}
+
+func (s *mcpServer) diffRuns(args map[string]interface{}) (string, error) {
+	base, err := s.loadReport(args, "base")
+	if err != nil {
+		return "", err
+	}
+	head, err := s.loadReport(args, "head")
+	if err != nil {
+		return "", err
+	}
+	threshold := 100 * time.Millisecond
+	if v, ok := args["threshold"].(string); ok && v != "" {
+		if threshold, err = time.ParseDuration(v); err != nil {
+			return "", fmt.Errorf("invalid threshold: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeGithubComment(&buf, compareReports(base, head), threshold, 20, "")
+	return buf.String(), nil
+}
+
+func (s *mcpServer) recommendations(args map[string]interface{}) (string, error) {
+	report, err := s.loadReport(args, "report")
+	if err != nil {
+		return "", err
+	}
+	a, err := analyze(context.Background(), report)
+	if err != nil {
+		return "", err
+	}
+	return marshalIndent(a)
+}
+
+func marshalIndent(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	err := encodeJSON(&buf, v)
+	return buf.String(), err
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parseBuckets are the upper bounds, in seconds, of the parse duration histogram.
+var parseBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// exemplar links an observation to the report it was made for.
+type exemplar struct {
+	reportID string
+	value    float64
+	at       time.Time
+}
+
+// serverMetrics holds goat's own operational metrics.
+type serverMetrics struct {
+	mu sync.Mutex
+
+	requests       map[[2]string]uint64 // by route and status code.
+	requestSum     map[string]float64   // request seconds by route.
+	requestCount   map[string]uint64    // requests by route.
+	parseCounts    []uint64             // by parse bucket, the last one is +Inf.
+	parseExemplars []*exemplar          // last observation by parse bucket.
+	parseSum       float64
+	parseErrors    uint64
+	summaryHits    uint64
+	summaryMisses  uint64
+}
+
+// stats are the metrics of the running process.
+var stats = &serverMetrics{
+	requests:       make(map[[2]string]uint64),
+	requestSum:     make(map[string]float64),
+	requestCount:   make(map[string]uint64),
+	parseCounts:    make([]uint64, len(parseBuckets)+1),
+	parseExemplars: make([]*exemplar, len(parseBuckets)+1),
+}
+
+// observeRequest records a served request.
+func (m *serverMetrics) observeRequest(route string, status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{route, strconv.Itoa(status)}]++
+	m.requestSum[route] += d.Seconds()
+	m.requestCount[route]++
+}
+
+// observeParse records a report parse of the given report id.
+func (m *serverMetrics) observeParse(d time.Duration, reportID string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.parseErrors++
+		return
+	}
+	i := sort.SearchFloat64s(parseBuckets, d.Seconds())
+	m.parseCounts[i]++
+	m.parseExemplars[i] = &exemplar{reportID: reportID, value: d.Seconds(), at: time.Now()}
+	m.parseSum += d.Seconds()
+}
+
+// observeSummaryCache records a summary cache lookup.
+func (m *serverMetrics) observeSummaryCache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.summaryHits++
+	} else {
+		m.summaryMisses++
+	}
+}
+
+// metricsWriter writes metric families in the prometheus text format or, with
+// openMetrics set, in the OpenMetrics format.
+type metricsWriter struct {
+	w           io.Writer
+	openMetrics bool
+}
+
+// family writes the metadata of a metric family. OpenMetrics names counter
+// families without their _total suffix.
+func (mw metricsWriter) family(name, typ, help string) {
+	if mw.openMetrics && typ == "counter" {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	fmt.Fprintf(mw.w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(mw.w, "# TYPE %s %s\n", name, typ)
+}
+
+// sample writes a sample, with its exemplar in OpenMetrics.
+func (mw metricsWriter) sample(name, labels string, value interface{}, ex *exemplar) {
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(mw.w, "%s%s %v", name, labels, value)
+	if mw.openMetrics && ex != nil {
+		fmt.Fprintf(mw.w, " # {%s} %g %.3f", label("report_id", ex.reportID), ex.value, float64(ex.at.UnixMilli())/1000)
+	}
+	fmt.Fprintln(mw.w)
+}
+
+// labelEscaper escapes label values as the prometheus text format expects.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// label formats a name="value" label pair.
+func label(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
+
+// write writes the metrics, with the figures of the report of the source.
+func (m *serverMetrics) write(ctx context.Context, mw metricsWriter, source *servedSource) {
+	// read the served report size first; loading a report records parse metrics.
+	source.Lock()
+	size := source.size
+	report := source.report
+	source.Unlock()
+
+	// count reports.
+	served := 1
+	if servingSeveral() {
+		served = len(reportSources())
+	}
+	recorded := -1
+	if history != nil {
+		n, err := history.Count(ctx)
+		if err != nil {
+			log.Printf("failed to count recorded reports: %s", err)
+		} else {
+			recorded = n
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// requests.
+	mw.family("goat_http_requests_total", "counter", "HTTP requests served by goat.")
+	for _, k := range sortedKeys(m.requests) {
+		mw.sample("goat_http_requests_total", label("route", k[0])+","+label("code", k[1]), m.requests[k], nil)
+	}
+	mw.family("goat_http_request_duration_seconds", "summary", "HTTP request durations.")
+	for _, route := range sortedKeys(m.requestCount) {
+		mw.sample("goat_http_request_duration_seconds_sum", label("route", route), m.requestSum[route], nil)
+		mw.sample("goat_http_request_duration_seconds_count", label("route", route), m.requestCount[route], nil)
+	}
+
+	// parsing.
+	mw.family("goat_report_parse_duration_seconds", "histogram", "Startup report parse durations.")
+	var cumulative uint64
+	for i, count := range m.parseCounts {
+		cumulative += count
+		le := "+Inf"
+		if i < len(parseBuckets) {
+			le = strconv.FormatFloat(parseBuckets[i], 'g', -1, 64)
+		}
+		mw.sample("goat_report_parse_duration_seconds_bucket", label("le", le), cumulative, m.parseExemplars[i])
+	}
+	mw.sample("goat_report_parse_duration_seconds_sum", "", m.parseSum, nil)
+	mw.sample("goat_report_parse_duration_seconds_count", "", cumulative, nil)
+	mw.family("goat_report_parse_errors_total", "counter", "Startup reports that failed to parse.")
+	mw.sample("goat_report_parse_errors_total", "", m.parseErrors, nil)
+
+	// summary cache.
+	mw.family("goat_summary_cache_requests_total", "counter", "Summary cache lookups by result.")
+	mw.sample("goat_summary_cache_requests_total", `result="hit"`, m.summaryHits, nil)
+	mw.sample("goat_summary_cache_requests_total", `result="miss"`, m.summaryMisses, nil)
+
+	// storage.
+	mw.family("goat_reports", "gauge", "Startup reports served.")
+	mw.sample("goat_reports", "", served, nil)
+	if recorded >= 0 {
+		mw.family("goat_history_reports", "gauge", "Startup reports recorded in the history.")
+		mw.sample("goat_history_reports", "", recorded, nil)
+	}
+	if size > 0 {
+		mw.family("goat_report_size_bytes", "gauge", "Size of the served startup report file.")
+		mw.sample("goat_report_size_bytes", "", size, nil)
+	}
+
+	if report != nil {
+		mw.report(report.Timeline)
+	}
+
+	if mw.openMetrics {
+		fmt.Fprintln(mw.w, "# EOF")
+	}
+}
+
+// report writes the startup figures of the served report.
+func (mw metricsWriter) report(t Timeline) {
+	mw.family("goat_report_startup_duration_seconds", "gauge", "Startup time of the served report.")
+	mw.sample("goat_report_startup_duration_seconds", "", t.Duration().Seconds(), nil)
+	mw.family("goat_report_events", "gauge", "Events of the served report.")
+	mw.sample("goat_report_events", "", len(t.Events), nil)
+
+	// steps by name; bean keys would make too many series.
+	byName := stepDurationsByName(t)
+	mw.family("goat_report_step_duration_seconds", "gauge", "Summed durations of the steps of the served report by step name.")
+	for _, name := range sortedKeys(byName) {
+		mw.sample("goat_report_step_duration_seconds", label("step", name), byName[name].Seconds(), nil)
+	}
+
+	// milestones.
+	m := t.Milestones()
+	mw.family("goat_report_milestone_seconds", "gauge", "Time from the start of the served report to its context refresh, ApplicationStarted and readiness milestones.")
+	for _, milestone := range []struct {
+		name string
+		d    time.Duration
+	}{{"context_refreshed", m.ContextRefreshed}, {"started", m.Started}, {"ready", m.Ready}} {
+		if milestone.d > 0 {
+			mw.sample("goat_report_milestone_seconds", label("milestone", milestone.name), milestone.d.Seconds(), nil)
+		}
+	}
+}
+
+// stepDurationsByName sums the step durations by step name. Steps nested in a
+// step of the same name are already counted in it.
+func stepDurationsByName(t Timeline) map[string]time.Duration {
+	byName := make(map[string]time.Duration)
+	buildTree(t).Walk(func(n *Node) bool {
+		name := n.Event.StartupStep.Name
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.Event.StartupStep.Name == name {
+				return true
+			}
+		}
+		byName[name] += n.Event.Duration()
+		return true
+	})
+	return byName
+}
+
+// sortedKeys returns the map keys in a stable order.
+func sortedKeys[K string | [2]string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	// negotiate format.
+	mw := metricsWriter{w: w, openMetrics: strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")}
+	if mw.openMetrics {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	stats.write(r.Context(), mw, servedSourceOf(r.Context()))
+}
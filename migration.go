@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// environment is the environment the report was recorded in, e.g. prod.
+	environment string
+
+	// migrationEnvironments are the environments expected to run database
+	// migrations on boot.
+	migrationEnvironments = "local,dev,test"
+)
+
+// registerAnalysisFlags registers the analyzer flags on the flag set.
+func registerAnalysisFlags(fs *flag.FlagSet) {
+	fs.StringVar(&environment, "environment", "", "environment the reports were recorded in, e.g. prod. used to tell whether migrations should run on boot.")
+	fs.StringVar(&migrationEnvironments, "migration-environments", migrationEnvironments, "comma separated environments expected to run database migrations on boot.")
+}
+
+// isMigrationStep reports whether the step is about a Flyway or Liquibase bean.
+func isMigrationStep(s StartupStep) bool {
+	class := strings.ToLower(s.Tag("beanType") + " " + s.Tag("beanName"))
+	return strings.Contains(class, "flyway") || strings.Contains(class, "liquibase")
+}
+
+// migrationSteps returns the outermost migration steps and their total
+// duration; steps nested in another migration step are not counted twice.
+func migrationSteps(t Timeline) ([]int, time.Duration) {
+	nodes, total := outermostSteps(t, isMigrationStep)
+	var ids []int
+	for _, n := range nodes {
+		ids = append(ids, n.Event.StartupStep.ID)
+	}
+	return ids, total
+}
+
+// migrationsExpected reports whether migrations are expected to run on boot in
+// the configured environment. Without an environment, they always are.
+func migrationsExpected() bool {
+	if environment == "" {
+		return true
+	}
+	for _, env := range strings.Split(migrationEnvironments, ",") {
+		if strings.TrimSpace(env) == environment {
+			return true
+		}
+	}
+	return false
+}
+
+func checkMigrations(t Timeline) []Finding {
+	ids, total := migrationSteps(t)
+	if len(ids) == 0 {
+		return nil
+	}
+	percent := 0.0
+	if t.Duration() > 0 {
+		percent = 100 * float64(total) / float64(t.Duration())
+	}
+	findings := []Finding{{
+		Severity:   SeverityInfo,
+		Message:    fmt.Sprintf("database migrations took %s (%.1f%% of startup); the rest of startup took %s.", formatDuration(total), percent, formatDuration(t.Duration()-total)),
+		StepIDs:    ids,
+		DurationMs: millis(total),
+	}}
+	if !migrationsExpected() {
+		findings = append(findings, Finding{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("database migrations run on every boot in %s; run them as a separate deployment step, e.g. with spring.flyway.enabled=false or spring.liquibase.enabled=false.", environment),
+			StepIDs:    ids,
+			DurationMs: millis(total),
+		})
+	}
+	return findings
+}
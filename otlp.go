@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// otlpTrace converts the report into an otlp trace: every step becomes a span,
+// children of the span of their parent step, with the step tags as
+// attributes. Trace and span ids are derived from the report, so exporting
+// the same report twice yields the same trace.
+func otlpTrace(report *StartupReport, serviceName string) *collectortrace.ExportTraceServiceRequest {
+	// derive trace id.
+	seed := sha256.Sum256([]byte(report.ID + report.Timeline.StartTime.UTC().Format(time.RFC3339Nano)))
+	traceID := seed[:16]
+	spanID := func(n *Node) []byte {
+		var id [8]byte
+		binary.BigEndian.PutUint64(id[:], binary.BigEndian.Uint64(seed[16:24])^uint64(n.Event.StartupStep.ID+1))
+		return id[:]
+	}
+
+	// convert steps; repaired parents are followed instead of parentId, so
+	// spans never point at a missing or cyclic parent.
+	var spans []*tracepb.Span
+	end := report.Timeline.StartTime.Add(report.Timeline.Duration())
+	buildTree(report.Timeline).Walk(func(n *Node) bool {
+		step := n.Event.StartupStep
+		span := &tracepb.Span{
+			TraceId:           traceID,
+			SpanId:            spanID(n),
+			Name:              step.Name,
+			Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: uint64(n.Event.StartTime.UnixNano()),
+			EndTimeUnixNano:   uint64(n.Event.EndTime.UnixNano()),
+			Attributes:        []*commonpb.KeyValue{otlpInt("spring.startup.step.id", int64(step.ID))},
+		}
+		if n.Parent != nil {
+			span.ParentSpanId = spanID(n.Parent)
+		}
+
+		// unfinished steps, e.g. of failed startups, last until the timeline
+		// ends.
+		if n.Event.EndTime.IsZero() {
+			stepEnd := end
+			if stepEnd.Before(n.Event.StartTime) {
+				stepEnd = n.Event.StartTime
+			}
+			span.EndTimeUnixNano = uint64(stepEnd.UnixNano())
+			span.Attributes = append(span.Attributes, otlpBool("spring.startup.step.unfinished", true))
+			span.Status = &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "step never ended"}
+		}
+		for _, tag := range step.Tags {
+			span.Attributes = append(span.Attributes, otlpString(tag.Key, tag.Value))
+		}
+		spans = append(spans, span)
+		return true
+	})
+
+	// wrap spans.
+	resource := &resourcepb.Resource{Attributes: []*commonpb.KeyValue{otlpString("service.name", serviceName)}}
+	if v := report.SpringBootVersion; v != "" {
+		resource.Attributes = append(resource.Attributes, otlpString("spring.boot.version", v))
+	}
+	return &collectortrace.ExportTraceServiceRequest{ResourceSpans: []*tracepb.ResourceSpans{{
+		Resource: resource,
+		ScopeSpans: []*tracepb.ScopeSpans{{
+			Scope: &commonpb.InstrumentationScope{Name: instrumentationName},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func otlpString(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpBool(key string, value bool) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value}}}
+}
+
+func otlpInt(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}}
+}
+
+// otlpJSON encodes the trace in the otlp/json file format. Unlike the protobuf
+// json mapping, otlp/json encodes trace and span ids in hex, and enums as
+// integers.
+func otlpJSON(req *collectortrace.ExportTraceServiceRequest) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		ResourceSpans []struct {
+			Resource   json.RawMessage `json:"resource"`
+			ScopeSpans []struct {
+				Scope json.RawMessage          `json:"scope"`
+				Spans []map[string]interface{} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, rs := range doc.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				for _, key := range []string{"traceId", "spanId", "parentSpanId"} {
+					if v, ok := span[key].(string); ok {
+						id, err := base64.StdEncoding.DecodeString(v)
+						if err != nil {
+							return nil, err
+						}
+						span[key] = hex.EncodeToString(id)
+					}
+				}
+			}
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// pushTrace posts the trace to an otlp/http collector, e.g.
+// http://localhost:4318.
+func pushTrace(ctx context.Context, endpoint string, req *collectortrace.ExportTraceServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector replied %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// pageOptions are the report page rendering options.
+type pageOptions struct {
+	// CollapseBelow folds the steps shorter than it into one aggregated step
+	// per parent. 0 disables folding.
+	CollapseBelow time.Duration
+
+	// GroupAbove groups the siblings sharing a name into one aggregated step
+	// when there are more than it. 0 disables grouping.
+	GroupAbove int
+
+	// MaxDepth limits the rendered levels of the hierarchy; deeper subtrees are
+	// fetched from the api. 0 renders all levels.
+	MaxDepth int
+
+	// Sort is the children order: report, start or duration.
+	Sort string
+
+	// Order is the direction of Sort, asc or desc; empty keeps its natural
+	// direction, chronological or heaviest first.
+	Order string
+
+	// Page is the 1-based page of Size steps rendered; 0 renders all steps.
+	Page int
+	Size int
+
+	// Filter narrows the steps down to the matching ones.
+	Filter stepFilter
+
+	// Standalone inlines the assets and leaves out the links to the server,
+	// for pages viewed without it.
+	Standalone bool
+}
+
+// sort directions of the report page.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// defaultPageSize is the number of steps per page when paginating.
+const defaultPageSize = 100
+
+// pageRefresh is the interval the report page reloads itself at, e.g. on
+// dashboards; 0 disables reloading, except for live reports.
+var pageRefresh time.Duration
+
+// liveRefresh is the reload interval of live report pages.
+const liveRefresh = 5 * time.Second
+
+// refreshSeconds returns the reload interval of the report page in seconds,
+// at least 1, or 0 when it doesn't reload.
+func refreshSeconds() int {
+	refresh := pageRefresh
+	if refresh == 0 && live != nil {
+		refresh = liveRefresh
+	}
+	if refresh <= 0 {
+		return 0
+	}
+	return max(1, int(refresh.Round(time.Second)/time.Second))
+}
+
+// defaultPageOptions are the options used when the request doesn't set them;
+// snapshots are rendered with them.
+var defaultPageOptions = pageOptions{GroupAbove: 100, Sort: OrderReport}
+
+// parsePageOptions reads the page options from the request query, on top of
+// the ones of the ?view token, if any.
+func parsePageOptions(r *http.Request) (pageOptions, error) {
+	opts := defaultPageOptions
+	var err error
+	if v := r.URL.Query().Get("view"); v != "" {
+		if opts, err = decodeViewToken(v); err != nil {
+			return opts, err
+		}
+	}
+	if opts.CollapseBelow, err = durationParam(r, "collapseBelow", opts.CollapseBelow); err != nil {
+		return opts, err
+	}
+	if v := r.URL.Query().Get("groupAbove"); v != "" {
+		if opts.GroupAbove, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("invalid groupAbove: %w", err)
+		}
+	}
+	if v := r.URL.Query().Get("sort"); v != "" {
+		opts.Sort = v
+	}
+	if err := checkOrder(opts.Sort); err != nil {
+		return opts, err
+	}
+	if v := r.URL.Query().Get("depth"); v != "" {
+		if opts.MaxDepth, err = strconv.Atoi(v); err != nil {
+			return opts, fmt.Errorf("invalid depth: %w", err)
+		}
+	}
+	if opts.Filter, err = parseStepFilter(r, opts.Filter); err != nil {
+		return opts, err
+	}
+	if v := r.URL.Query().Get("order"); v != "" {
+		opts.Order = v
+	}
+	if opts.Order != "" && opts.Order != OrderAsc && opts.Order != OrderDesc {
+		return opts, fmt.Errorf("unknown order %q, expected %s or %s", opts.Order, OrderAsc, OrderDesc)
+	}
+	for _, p := range []struct {
+		name string
+		v    *int
+	}{{"page", &opts.Page}, {"size", &opts.Size}} {
+		if v := r.URL.Query().Get(p.name); v != "" {
+			if *p.v, err = strconv.Atoi(v); err != nil || *p.v < 1 {
+				return opts, fmt.Errorf("invalid %s: %s", p.name, v)
+			}
+		}
+	}
+	if opts.Size > 0 && opts.Page == 0 {
+		opts.Page = 1
+	}
+	return opts, nil
+}
+
+// PageStep represents a step rendered on the report page.
+type PageStep struct {
+	Events
+	Depth int
+
+	// Folded is the number of steps aggregated into this one, which is then a
+	// synthetic step; 0 for report steps.
+	Folded int
+
+	// Group is set when the step is a synthetic step aggregating siblings
+	// sharing a name.
+	Group *StepGroup
+
+	// Hidden is the number of descendants not rendered because of the depth
+	// limit.
+	Hidden int
+
+	// Context is set when the step doesn't match the filter, and is rendered
+	// for the matching steps under it.
+	Context bool
+
+	// SelfTime is the step duration not covered by its children.
+	SelfTime time.Duration
+
+	// Open is set when the step children are rendered after it, nested in its
+	// collapsible subtree; Close is the number of subtrees ending after it.
+	Open  bool
+	Close int
+}
+
+// StepGroup represents siblings sharing a name, aggregated into one step.
+type StepGroup struct {
+	Name     string
+	ParentID *int
+	Count    int
+	Max      time.Duration
+}
+
+// pageSteps returns the steps to render depth-first, so children follow their
+// parent, applying the page options.
+func pageSteps(t Timeline, opts pageOptions) []PageStep {
+	tree := buildTree(t)
+	tree.Sort(opts.Sort)
+	if natural := map[bool]string{true: OrderDesc, false: OrderAsc}[opts.Sort == OrderDuration]; opts.Order != "" && opts.Order != natural {
+		tree.Reverse()
+	}
+	context := make(map[*Node]bool)
+	if opts.Filter.active() {
+		tree.Roots = filterNodes(tree.Roots, opts.Filter, context)
+	}
+	folded := make(map[*Node]int)
+	groups := make(map[*Node]*StepGroup)
+	tree.Roots = foldNodes(groupNodes(tree.Roots, opts.GroupAbove, groups), opts.CollapseBelow, folded)
+
+	var steps []PageStep
+	tree.Walk(func(n *Node) bool {
+		// group and fold children before they're visited.
+		n.Children = foldNodes(groupNodes(n.Children, opts.GroupAbove, groups), opts.CollapseBelow, folded)
+		step := PageStep{Events: n.Event, Depth: n.Depth, Folded: folded[n], Group: groups[n], SelfTime: n.SelfTime, Context: context[n]}
+
+		// stop at the depth limit.
+		deeper := opts.MaxDepth <= 0 || n.Depth+1 < opts.MaxDepth
+		if !deeper {
+			(&Tree{Roots: n.Children}).Walk(func(*Node) bool {
+				step.Hidden++
+				return true
+			})
+		}
+		step.Open = deeper && len(n.Children) > 0
+		steps = append(steps, step)
+		return deeper
+	})
+
+	// close the subtrees: after a step, the open ones are its ancestors and
+	// itself if open, the next step needs only its own ancestors.
+	for i := range steps {
+		open := steps[i].Depth
+		if steps[i].Open {
+			open++
+		}
+		next := 0
+		if i+1 < len(steps) {
+			next = steps[i+1].Depth
+		}
+		steps[i].Close = open - next
+	}
+	return steps
+}
+
+// groupNodes replaces the sibling nodes sharing a name, when there are more
+// than above of them, by a single synthetic node in place of the first of them.
+// Its duration is the total of the group.
+func groupNodes(nodes []*Node, above int, groups map[*Node]*StepGroup) []*Node {
+	if above <= 0 || len(nodes) <= above {
+		return nodes
+	}
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		counts[n.Event.StartupStep.Name]++
+	}
+
+	var kept []*Node
+	byName := make(map[string]*Node)
+	for _, n := range nodes {
+		name := n.Event.StartupStep.Name
+		if counts[name] <= above {
+			kept = append(kept, n)
+			continue
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &Node{Event: Events{StartupStep: StartupStep{Name: name}, StartTime: n.Event.StartTime, EndTime: n.Event.StartTime}, Parent: n.Parent, Depth: n.Depth}
+			group := &StepGroup{Name: name}
+			if n.Parent != nil {
+				id := n.Parent.Event.StartupStep.ID
+				group.ParentID = &id
+			}
+			byName[name], groups[g] = g, group
+			kept = append(kept, g)
+		}
+		group := groups[g]
+		group.Count++
+		if n.Event.Duration() > group.Max {
+			group.Max = n.Event.Duration()
+		}
+		g.Event.EndTime = g.Event.EndTime.Add(n.Event.Duration())
+	}
+	return kept
+}
+
+// foldNodes replaces the sibling nodes shorter than min by a single synthetic
+// node, in place of the first of them, recording how many it aggregates.
+// Groups are folded as a whole.
+func foldNodes(nodes []*Node, min time.Duration, folded map[*Node]int) []*Node {
+	if min <= 0 {
+		return nodes
+	}
+	var kept []*Node
+	var other *Node
+	for _, n := range nodes {
+		if n.Event.Duration() >= min {
+			kept = append(kept, n)
+			continue
+		}
+		if other == nil {
+			other = &Node{Event: Events{StartTime: n.Event.StartTime, EndTime: n.Event.StartTime}, Parent: n.Parent, Depth: n.Depth}
+			kept = append(kept, other)
+		}
+		other.Event.EndTime = other.Event.EndTime.Add(n.Event.Duration())
+		folded[other]++
+	}
+	if other != nil {
+		other.Event.StartupStep.Name = fmt.Sprintf("other (%d steps)", folded[other])
+	}
+	return kept
+}
+
+// Pagination represents the page of steps rendered, with the view tokens of
+// the previous and next pages. Ranked is set when the steps are ranked by
+// duration across the tree rather than listed in tree order.
+type Pagination struct {
+	Page   int
+	Pages  int
+	Size   int
+	Total  int
+	Prev   string
+	Next   string
+	Ranked bool
+}
+
+// paginateSteps returns the steps of the page of the options. Pages are
+// rendered flat, without collapsible subtrees. Sorted by duration, the steps
+// are ranked across the tree, the heaviest first unless ordered asc, like an
+// event table; otherwise they are listed in tree order, indented by depth, and
+// their subtrees may start on an earlier page.
+func paginateSteps(steps []PageStep, opts pageOptions) ([]PageStep, *Pagination) {
+	size := opts.Size
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	ranked := opts.Sort == OrderDuration
+	if ranked {
+		steps = rankSteps(steps, opts.Order == OrderAsc)
+	}
+	p := &Pagination{Page: opts.Page, Size: size, Total: len(steps), Pages: (len(steps) + size - 1) / size, Ranked: ranked}
+	if p.Pages == 0 {
+		p.Pages = 1
+	}
+	from := min((opts.Page-1)*size, len(steps))
+	to := min(from+size, len(steps))
+	page := append([]PageStep(nil), steps[from:to]...)
+	for i := range page {
+		page[i].Open, page[i].Close = false, 0
+	}
+	link := func(n int) string {
+		o := opts
+		o.Page, o.Size = n, size
+		return encodeViewToken(o)
+	}
+	if opts.Page > 1 {
+		p.Prev = link(min(opts.Page-1, p.Pages))
+	}
+	if opts.Page < p.Pages {
+		p.Next = link(opts.Page + 1)
+	}
+	return page, p
+}
+
+// rankSteps returns the steps, without the filter context ones, by duration:
+// the heaviest first, or the lightest when asc. Ranked steps are not indented,
+// their parents are elsewhere in the ranking.
+func rankSteps(steps []PageStep, asc bool) []PageStep {
+	var ranked []PageStep
+	for _, s := range steps {
+		if !s.Context {
+			s.Depth = 0
+			ranked = append(ranked, s)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if asc {
+			return ranked[i].Duration() < ranked[j].Duration()
+		}
+		return ranked[i].Duration() > ranked[j].Duration()
+	})
+	return ranked
+}
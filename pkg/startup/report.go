@@ -0,0 +1,204 @@
+// Package startup parses spring boot actuator startup reports, as served by
+// the /actuator/startup endpoint, and computes their step durations.
+package startup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// Report represents the spring actuator startup report.
+type Report struct {
+	SpringBootVersion string   `json:"springBootVersion"`
+	Timeline          Timeline `json:"timeline"`
+
+	// ID identifies the report content: it is derived from a hash of the json.
+	ID string `json:"-"`
+
+	// Truncation is set when events were dropped while parsing the report.
+	Truncation *Truncation `json:"-"`
+}
+
+// Truncation describes the events dropped from a report.
+type Truncation struct {
+	Total int `json:"total"`
+	Kept  int `json:"kept"`
+}
+
+// Tag represents the springboot startup step tags.
+type Tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Step represents the springboot startup step.
+type Step struct {
+	Name     string `json:"name"`
+	ID       int    `json:"id"`
+	ParentID *int   `json:"parentId,omitempty"`
+	Tags     []Tag  `json:"tags"`
+}
+
+// Tag returns the value of the step tag with the given key, or "" if absent.
+func (s Step) Tag(key string) string {
+	for _, t := range s.Tags {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Key identifies the step across reports: its name, qualified by the bean
+// name when the step is about a bean.
+func (s Step) Key() string {
+	if bean := s.Tag("beanName"); bean != "" {
+		return s.Name + " [" + bean + "]"
+	}
+	return s.Name
+}
+
+// Event represents the springboot startup timeline events.
+type Event struct {
+	StartupStep Step      `json:"startupStep"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+}
+
+// Duration calculates the startup time of the event.
+func (e Event) Duration() time.Duration {
+	return e.EndTime.Sub(e.StartTime)
+}
+
+// IsRoot reports whether the event is a top-level step (no parent).
+func (e Event) IsRoot() bool {
+	return e.StartupStep.ParentID == nil
+}
+
+// Timeline represents the springboot startup timeline.
+type Timeline struct {
+	StartTime time.Time `json:"startTime"`
+	Events    []Event   `json:"events"`
+}
+
+// Duration calculates the timeline duration.
+func (t Timeline) Duration() time.Duration {
+	// get max endTime.
+	var max time.Time
+	for _, e := range t.Events {
+		if e.EndTime.After(max) {
+			max = e.EndTime
+		}
+	}
+	return max.Sub(t.StartTime)
+}
+
+// RootEvents returns the top-level events of the timeline, ordered by start time.
+func (t Timeline) RootEvents() []Event {
+	var roots []Event
+	for _, e := range t.Events {
+		if e.IsRoot() {
+			roots = append(roots, e)
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].StartTime.Before(roots[j].StartTime)
+	})
+	return roots
+}
+
+// Slowest returns the n slowest events of the timeline, slowest first.
+func (t Timeline) Slowest(n int) []Event {
+	events := append([]Event(nil), t.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Duration() > events[j].Duration()
+	})
+	if len(events) > n {
+		events = events[:n]
+	}
+	return events
+}
+
+// Milestone step names.
+const (
+	StepContextRefresh = "spring.context.refresh"
+	StepStarted        = "spring.boot.application.started"
+	StepReady          = "spring.boot.application.ready"
+)
+
+// Milestones represents the time from the start of the timeline to the end of
+// the key startup steps. A milestone is zero when the report doesn't record
+// its step.
+type Milestones struct {
+	ContextRefreshed time.Duration
+	Started          time.Duration
+	Ready            time.Duration
+}
+
+// Milestones returns the time to context refresh, to ApplicationStarted and
+// to readiness.
+func (t Timeline) Milestones() Milestones {
+	timeTo := func(name string) time.Duration {
+		for _, e := range t.Events {
+			if e.StartupStep.Name == name && !e.EndTime.IsZero() {
+				return e.EndTime.Sub(t.StartTime)
+			}
+		}
+		return 0
+	}
+	return Milestones{
+		ContextRefreshed: timeTo(StepContextRefresh),
+		Started:          timeTo(StepStarted),
+		Ready:            timeTo(StepReady),
+	}
+}
+
+// Parse reads a json startup report.
+func Parse(r io.Reader) (*Report, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	if err := json.Unmarshal(content, report); err != nil {
+		return nil, err
+	}
+	report.ID = ContentID(content)
+	return report, nil
+}
+
+// ContentID returns the report ID of the json report content.
+func ContentID(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Summary represents the headline numbers of a report.
+type Summary struct {
+	SpringBootVersion string
+	StartupTime       time.Duration
+	Milestones        Milestones
+	Steps             int
+
+	// Phases are the top-level steps, ordered by start time.
+	Phases []Event
+
+	// Slowest are the 5 slowest steps, slowest first.
+	Slowest []Event
+}
+
+// Summary returns the headline numbers of the report.
+func (r *Report) Summary() Summary {
+	return Summary{
+		SpringBootVersion: r.SpringBootVersion,
+		StartupTime:       r.Timeline.Duration(),
+		Milestones:        r.Timeline.Milestones(),
+		Steps:             len(r.Timeline.Events),
+		Phases:            r.Timeline.RootEvents(),
+		Slowest:           r.Timeline.Slowest(5),
+	}
+}
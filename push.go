@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// pushSource is the history source of the reports pushed to /api/reports.
+const pushSource = "api"
+
+// pushMetadata are the query parameters recorded as labels with a pushed
+// report, on top of the -labels ones and the ?labels=key=value,... ones.
+var pushMetadata = []string{"sha", "branch", "environment"}
+
+// PushedReport represents a report pushed to /api/reports.
+type PushedReport struct {
+	ID            int64             `json:"id"`
+	ReportID      string            `json:"reportId"`
+	Labels        map[string]string `json:"labels"`
+	StartupTimeMs float64           `json:"startupTimeMs"`
+	URL           string            `json:"url"`
+}
+
+func handlePushReport(w http.ResponseWriter, r *http.Request) {
+	// get labels.
+	labels, err := parseLabels(r.URL.Query().Get("labels"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for k, v := range historyLabels {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	for _, key := range pushMetadata {
+		if v := strings.TrimSpace(r.URL.Query().Get(key)); v != "" {
+			labels[key] = v
+		}
+	}
+
+	// get report; when pushes are verified, it must be signed.
+	if maxReportSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxReportSize)
+	}
+	body, err := signedBody(r.Header.Get(appHeader), r.Header.Get(signatureHeader), r.Body)
+	if err != nil {
+		log.Printf("failed to verify pushed report: %s", err)
+		http.Error(w, err.Error(), bodyErrorStatus(err, http.StatusUnauthorized))
+		return
+	}
+	content, err := readReportContent(body)
+	if err != nil {
+		http.Error(w, err.Error(), bodyErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+	report, err := decodeReport(r.Context(), content)
+	if err == nil {
+		err = validateReport(report)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid report: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// record report.
+	id, err := history.Save(r.Context(), report, pushSource, labels)
+	if err != nil {
+		log.Printf("failed to record report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	url := fmt.Sprintf("history/%d", id)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", url)
+	w.WriteHeader(http.StatusCreated)
+	if err := encodeJSON(w, PushedReport{ID: id, ReportID: report.ID, Labels: labels, StartupTimeMs: millis(report.Timeline.Duration()), URL: url}); err != nil {
+		log.Printf("failed to write json: %s", err)
+	}
+}
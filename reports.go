@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportFlags collects the repeated -report flags.
+type reportFlags []string
+
+// String implements flag.Value.
+func (f *reportFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value.
+func (f *reportFlags) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// reportNamePattern matches the names reports can be given with name=path.
+var reportNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// splitReportFlag returns the name and path of a name=path or path report
+// flag; unnamed reports are named after their path.
+func splitReportFlag(value string) (string, string) {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || !reportNamePattern.MatchString(name) {
+		return reportName(value), value
+	}
+	return name, path
+}
+
+// servedReports are the reports served under /reports/{name}/ when serving
+// several; the reports of dir are listed again on every lookup, so new
+// services show up without a restart.
+var servedReports struct {
+	sync.Mutex
+	fixed   []*servedSource
+	dir     string
+	sources map[string]*servedSource // by path, reused across listings.
+}
+
+// servingSeveral reports whether several reports are served.
+func servingSeveral() bool {
+	return len(servedReports.fixed) > 0 || servedReports.dir != ""
+}
+
+// setServedReports configures the reports served when serving several: the
+// name=path or path report flags, and every report of the directory.
+func setServedReports(paths []string, dir string) {
+	servedReports.sources = make(map[string]*servedSource)
+	servedReports.dir = dir
+	for _, path := range paths {
+		name, p := splitReportFlag(path)
+		servedReports.fixed = append(servedReports.fixed, &servedSource{name: uniqueReportName(name), path: p})
+	}
+}
+
+// uniqueReportName returns the name, numbered when an earlier report took it.
+// It must be called with servedReports locked or before serving.
+func uniqueReportName(name string) string {
+	taken := func(name string) bool {
+		for _, s := range servedReports.fixed {
+			if s.name == name {
+				return true
+			}
+		}
+		for _, s := range servedReports.sources {
+			if s.name == name {
+				return true
+			}
+		}
+		return false
+	}
+	unique := name
+	for n := 2; taken(unique); n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	return unique
+}
+
+// reportName names the report after its file, without extensions.
+func reportName(path string) string {
+	name := filepath.Base(strings.TrimSuffix(path, "/"))
+	for _, ext := range []string{".json.gz", ".json", ".zip"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// reportSources returns the served reports, the report flags first and then
+// the directory ones by name.
+func reportSources() []*servedSource {
+	servedReports.Lock()
+	defer servedReports.Unlock()
+	sources := append([]*servedSource(nil), servedReports.fixed...)
+
+	// list directory reports.
+	if servedReports.dir != "" {
+		entries, err := os.ReadDir(servedReports.dir)
+		if err != nil {
+			log.Printf("failed to read reports directory: %s", err)
+		}
+		var listed []*servedSource
+		for _, entry := range entries {
+			if !entry.IsDir() && !isReportFile(entry.Name()) {
+				continue
+			}
+			path := filepath.Join(servedReports.dir, entry.Name())
+			s, ok := servedReports.sources[path]
+			if !ok {
+				s = &servedSource{name: uniqueReportName(reportName(path)), path: path}
+				servedReports.sources[path] = s
+			}
+			listed = append(listed, s)
+		}
+		sort.SliceStable(listed, func(i, j int) bool { return listed[i].name < listed[j].name })
+		sources = append(sources, listed...)
+	}
+
+	return sources
+}
+
+// reportSource returns the served report with the name, or nil.
+func reportSource(name string) *servedSource {
+	for _, s := range reportSources() {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// withReportParam returns the request with the served report named by its
+// ?report=<name> parameter, if any, or false when there's no such report.
+func withReportParam(r *http.Request) (*http.Request, bool) {
+	name := r.URL.Query().Get("report")
+	if name == "" {
+		return r, true
+	}
+	s := reportSource(name)
+	if s == nil {
+		return r, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), sourceKey{}, s)), true
+}
+
+// severalRoutes serves the index of the reports at / and every report under
+// /reports/{name}/, with the routes of a single report.
+func severalRoutes(reportMux *http.ServeMux) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /static/", reportMux)
+	mux.Handle("GET /metrics", reportMux)
+	mux.Handle("GET /timeline.svg", reportMux)
+	mux.Handle("GET /badge.svg", reportMux)
+	if history != nil {
+		mux.Handle("GET /history", reportMux)
+		mux.Handle("GET /history/", reportMux)
+		mux.Handle("GET /api/history", reportMux)
+		mux.Handle("GET /api/history/", reportMux)
+		mux.Handle("GET /api/trends", reportMux)
+		mux.Handle("GET /api/leaderboard", reportMux)
+		mux.Handle("GET /leaderboard", reportMux)
+		mux.Handle("POST /api/reports", reportMux)
+		mux.Handle("POST /api/jobs", reportMux)
+		mux.Handle("GET /api/jobs", reportMux)
+		mux.Handle("GET /api/jobs/", reportMux)
+	}
+	mux.Handle("GET /{$}", instrument("GET /{$}", withTimeout(http.HandlerFunc(handleReportIndex))))
+	mux.HandleFunc("/reports/{name}/", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		s := reportSource(name)
+		if s == nil {
+			http.NotFound(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), sourceKey{}, s)
+		http.StripPrefix("/reports/"+name, reportMux).ServeHTTP(w, r.WithContext(ctx))
+	})
+	return mux
+}
+
+// ReportEntry represents a served report on the index page.
+type ReportEntry struct {
+	Name        string
+	StartupTime time.Duration
+	Stale       bool
+	Err         error
+}
+
+func handleReportIndex(w http.ResponseWriter, r *http.Request) {
+	// load reports.
+	var entries []ReportEntry
+	for _, s := range reportSources() {
+		entry := ReportEntry{Name: s.name}
+		report, err := s.load(r.Context())
+		if err != nil {
+			entry.Err = err
+		} else {
+			entry.StartupTime, entry.Stale = report.Timeline.Duration(), report.Stale != nil
+		}
+		entries = append(entries, entry)
+	}
+
+	// render page.
+	page, err := renderReportsPage(entries)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// renderReportsPage renders the index of the served reports.
+func renderReportsPage(entries []ReportEntry) ([]byte, error) {
+	// load template.
+	funcs := template.FuncMap{"classBasedOnDuration": classBasedOnDuration}
+	tpl, err := template.New("").Funcs(funcs).ParseFS(files, "web/reports.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// render template.
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "reports.html", entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/corabank/goat/pkg/startup"
+)
+
+// isRunnerStep reports whether the step is about an ApplicationRunner or a
+// CommandLineRunner, going by its name or bean.
+func isRunnerStep(s StartupStep) bool {
+	class := strings.ToLower(s.Name + " " + s.Tag("beanType") + " " + s.Tag("beanName"))
+	return strings.Contains(class, "runner")
+}
+
+// runnerPhase returns the time between ApplicationStarted and readiness, which
+// spring boot spends calling the runners, and whether the report records it.
+func runnerPhase(t Timeline) (time.Duration, bool) {
+	var started, ready *Events
+	for i, e := range t.Events {
+		switch e.StartupStep.Name {
+		case startup.StepStarted:
+			started = &t.Events[i]
+		case startup.StepReady:
+			ready = &t.Events[i]
+		}
+	}
+	if started == nil || ready == nil || !ready.StartTime.After(started.EndTime) {
+		return 0, false
+	}
+	return ready.StartTime.Sub(started.EndTime), true
+}
+
+func checkRunners(t Timeline) []Finding {
+	var findings []Finding
+
+	// runner steps after the context refresh.
+	refreshed := t.StartTime.Add(t.Milestones().ContextRefreshed)
+	nodes, _ := outermostSteps(t, isRunnerStep)
+	for _, n := range nodes {
+		if n.Event.StartTime.Before(refreshed) || n.Event.Duration() < 100*time.Millisecond {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("runner %s took %s after the context refresh, delaying readiness; move warm-up logic to the background or after readiness.", n.Event.StartupStep.Key(), formatDuration(n.Event.Duration())),
+			StepIDs:    []int{n.Event.StartupStep.ID},
+			DurationMs: millis(n.Event.Duration()),
+		})
+	}
+
+	// the runner phase as a whole.
+	if d, ok := runnerPhase(t); ok && d >= 100*time.Millisecond {
+		findings = append(findings, Finding{
+			Severity:   SeverityInfo,
+			Message:    fmt.Sprintf("application and command line runners took %s between ApplicationStarted and readiness.", formatDuration(d)),
+			DurationMs: millis(d),
+		})
+	}
+	return findings
+}
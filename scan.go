@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scanSteps are the names of the steps scanning the classpath for components.
+var scanSteps = map[string]bool{
+	"spring.context.config-classes.parse":       true,
+	"spring.context.component-classes.register": true,
+	"spring.data.repository.scanning":           true,
+}
+
+// scanPackage returns the base packages scanned by the step, if tagged.
+func scanPackage(s StartupStep) string {
+	for _, key := range []string{"basePackages", "basePackage", "packages"} {
+		if v := s.Tag(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func checkComponentScan(t Timeline) []Finding {
+	// aggregate scan steps; nested scans belong to their outermost step.
+	var ids []int
+	var total time.Duration
+	byPackage := make(map[string]time.Duration)
+	buildTree(t).Walk(func(n *Node) bool {
+		step := n.Event.StartupStep
+		if !scanSteps[step.Name] {
+			return true
+		}
+		ids = append(ids, step.ID)
+		total += n.Event.Duration()
+		if pkg := scanPackage(step); pkg != "" {
+			byPackage[pkg] += n.Event.Duration()
+		}
+		return false
+	})
+	if len(ids) == 0 || t.Duration() == 0 {
+		return nil
+	}
+
+	// scan time by package, slowest first.
+	message := fmt.Sprintf("component scanning took %s (%.1f%% of startup)", formatDuration(total), 100*float64(total)/float64(t.Duration()))
+	packages := sortedKeys(byPackage)
+	sort.SliceStable(packages, func(i, j int) bool {
+		return byPackage[packages[i]] > byPackage[packages[j]]
+	})
+	var parts []string
+	for _, pkg := range packages {
+		parts = append(parts, fmt.Sprintf("%s %s", pkg, formatDuration(byPackage[pkg])))
+	}
+	if len(parts) > 0 {
+		message += ": " + strings.Join(parts, ", ")
+	}
+
+	// scanning dominates startup.
+	severity := SeverityInfo
+	if float64(total) >= 0.2*float64(t.Duration()) {
+		severity = SeverityWarning
+		message += "; narrow scanBasePackages or @ComponentScan to the packages holding components, or add spring-context-indexer or AOT processing to skip scanning at runtime."
+	} else {
+		message += "."
+	}
+	return []Finding{{
+		Severity:   severity,
+		Message:    message,
+		StepIDs:    ids,
+		DurationMs: millis(total),
+	}}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// checkTemplates makes the server render every page and run every analysis
+// rule against a sample report before serving.
+var checkTemplates bool
+
+// sampleReport returns a small report touching the page features and the
+// analysis rules: nested beans, a datasource, migrations, the web server and
+// the startup milestones.
+func sampleReport() *StartupReport {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+	parent := func(id int) *int {
+		return &id
+	}
+	bean := func(id, parentID, from, to int, name, beanType string) Events {
+		return Events{
+			StartupStep: StartupStep{Name: "spring.beans.instantiate", ID: id, ParentID: parent(parentID), Tags: []Tags{{Key: "beanName", Value: name}, {Key: "beanType", Value: beanType}}},
+			StartTime:   at(from),
+			EndTime:     at(to),
+		}
+	}
+	report := &StartupReport{
+		SpringBootVersion: "3.2.0",
+		Timeline: Timeline{StartTime: start, Events: []Events{
+			{StartupStep: StartupStep{Name: "spring.boot.application.starting", ID: 0, Tags: []Tags{{Key: "mainApplicationClass", Value: "com.example.Sample"}}}, StartTime: at(0), EndTime: at(10)},
+			{StartupStep: StartupStep{Name: "spring.context.component-classes.register", ID: 1, Tags: []Tags{{Key: "basePackages", Value: "com.example"}}}, StartTime: at(10), EndTime: at(300)},
+			bean(3, 2, 400, 1600, "dataSource", "com.zaxxer.hikari.HikariDataSource"),
+			bean(4, 2, 1600, 2400, "flyway", "org.flywaydb.core.Flyway"),
+			bean(5, 2, 2400, 2600, "tomcatServletWebServerFactory", "org.springframework.boot.web.embedded.tomcat.TomcatServletWebServerFactory"),
+			bean(6, 7, 2650, 2700, "sampleRepository", "com.example.SampleRepository"),
+			bean(7, 2, 2600, 2800, "sampleService", "com.example.SampleService"),
+			{StartupStep: StartupStep{Name: "spring.context.refresh", ID: 2}, StartTime: at(300), EndTime: at(3000)},
+			{StartupStep: StartupStep{Name: "spring.boot.application.started", ID: 8}, StartTime: at(3000), EndTime: at(3010)},
+			{StartupStep: StartupStep{Name: "spring.boot.application.ready", ID: 9}, StartTime: at(3010), EndTime: at(3020)},
+		}},
+	}
+	report.ID = "self-check"
+	return report
+}
+
+// selfCheck renders every page and runs every analysis rule against the
+// sample report, returning all their errors.
+func selfCheck(ctx context.Context) (errs []error) {
+	report := sampleReport()
+	served := &ServedReport{StartupReport: report}
+	stale := &ServedReport{StartupReport: report, Stale: &Staleness{LoadedAt: time.Now(), Err: errors.New("sample error")}}
+
+	// run rules; a panicking rule would fail every analysis request.
+	for _, rule := range rules {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					errs = append(errs, fmt.Errorf("rule %s panicked: %v", rule.ID, r))
+				}
+			}()
+			rule.Check(report.Timeline)
+		}()
+	}
+
+	// render pages.
+	pages := []struct {
+		name   string
+		render func() ([]byte, error)
+	}{
+		{"report page", func() ([]byte, error) { return renderReportPage(ctx, served, defaultPageOptions) }},
+		{"stale report page", func() ([]byte, error) { return renderReportPage(ctx, stale, defaultPageOptions) }},
+		{"standalone report page", func() ([]byte, error) {
+			return renderReportPage(ctx, served, pageOptions{Sort: defaultPageOptions.Sort, Standalone: true, CollapseBelow: 100 * time.Millisecond, MaxDepth: 1})
+		}},
+		{"filtered report page", func() ([]byte, error) {
+			return renderReportPage(ctx, served, pageOptions{Sort: defaultPageOptions.Sort, Filter: stepFilter{Name: "beans", Tag: "beanName:sample"}, Order: OrderDesc, Page: 1, Size: 2})
+		}},
+		{"flame graph page", func() ([]byte, error) { return renderFlamegraphPage(served) }},
+		{"compare page", func() ([]byte, error) { return renderComparePage(compareReports(report, report), 100*time.Millisecond) }},
+		{"upload page", func() ([]byte, error) { return renderUploadPage("sample error") }},
+		{"history page", func() ([]byte, error) {
+			return renderHistoryPage([]HistoryEntry{
+				{ID: 2, ReportID: report.ID, RecordedAt: time.Now(), Source: "upload", Labels: map[string]string{"env": "dev"}, StartupTimeMs: 3020},
+				{ID: 1, ReportID: report.ID, RecordedAt: time.Now(), Source: "upload", StartupTimeMs: 2900},
+			})
+		}},
+		{"leaderboard page", func() ([]byte, error) {
+			return renderLeaderboardPage(Leaderboard{Apps: 2, Entries: []LeaderboardEntry{{Fingerprint: "spring.beans.instantiate [com.zaxxer.hikari.HikariDataSource]", Apps: []string{"billing", "orders"}, TotalMs: 2400, AvgMs: 1200, MaxMs: 1300}}})
+		}},
+		{"reports page", func() ([]byte, error) {
+			return renderReportsPage([]ReportEntry{{Name: "sample", StartupTime: report.Timeline.Duration()}, {Name: "broken", Err: errors.New("sample error")}})
+		}},
+	}
+	for _, page := range pages {
+		if _, err := page.render(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to render the %s: %w", page.name, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServedReport is the report served by goat, with how fresh it is.
+type ServedReport struct {
+	*StartupReport
+
+	// Stale is set when the report could not be reloaded and the last
+	// successfully parsed copy is served instead.
+	Stale *Staleness
+}
+
+// Staleness describes why a previously loaded report is being served.
+type Staleness struct {
+	LoadedAt time.Time
+	Err      error
+}
+
+// servedSource is a report source served by goat. It remembers the last
+// successfully parsed report, and the file it was read from.
+type servedSource struct {
+	sync.Mutex
+	name     string // path of the report under /reports/, when serving several.
+	path     string
+	report   *StartupReport
+	loadedAt time.Time
+	target   string
+	modTime  time.Time
+	size     int64
+	fetching bool  // whether the url is being fetched.
+	err      error // why the last load failed, if it did.
+}
+
+// lastGood is the source of the report served when serving a single one.
+var lastGood = &servedSource{}
+
+// pollInterval is the interval the served reports are polled at. Between
+// polls, requests reuse the polled copy of url reports.
+var pollInterval time.Duration
+
+// sourceKey is the context key of the source of the report being served.
+type sourceKey struct{}
+
+// servedSourceOf returns the source of the report served to the request.
+func servedSourceOf(ctx context.Context) *servedSource {
+	if s, ok := ctx.Value(sourceKey{}).(*servedSource); ok {
+		return s
+	}
+	return lastGood
+}
+
+// loadServedReport loads the report served to the request.
+func loadServedReport(ctx context.Context) (*ServedReport, error) {
+	if live != nil {
+		return &ServedReport{StartupReport: live.snapshot()}, nil
+	}
+	return servedSourceOf(ctx).load(ctx)
+}
+
+// load loads the report of the source. The report path may be a symlink
+// rotated by deployment tooling or a directory: it is resolved on every load
+// and the report is re-parsed only when the target or its contents changed.
+// Urls are fetched again once their copy is older than the poll interval, or
+// on every load when not polling. When the report is unreadable, e.g. while
+// being rewritten, the last successfully parsed copy is served and marked
+// stale.
+func (s *servedSource) load(ctx context.Context) (*ServedReport, error) {
+	return s.loadWithin(ctx, pollInterval)
+}
+
+// reload loads the report of the source like load, always fetching urls.
+func (s *servedSource) reload(ctx context.Context) (*ServedReport, error) {
+	return s.loadWithin(ctx, 0)
+}
+
+// loadWithin loads the report of the source, reusing the copy of a url
+// fetched less than maxAge ago.
+func (s *servedSource) loadWithin(ctx context.Context, maxAge time.Duration) (*ServedReport, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	// resolve report file.
+	var report *StartupReport
+	var target string
+	var info os.FileInfo
+	var err error
+	switch {
+	case s.path == stdinSource:
+		report, err = readStdinReport()
+	case isURL(s.path):
+		// reuse the copy while fresh or while another request fetches it.
+		if s.report != nil && (s.fetching || maxAge > 0 && time.Since(s.loadedAt) < maxAge) {
+			return s.copy(), nil
+		}
+
+		// fetch without the lock, so requests don't queue behind a slow
+		// actuator.
+		s.fetching = true
+		s.Unlock()
+		report, err = fetchReport(ctx, s.path)
+		s.Lock()
+		s.fetching = false
+	default:
+		target, err = resolveReportFile(s.path)
+		if err == nil {
+			info, err = os.Stat(target)
+		}
+		if err == nil && s.report != nil && target == s.target && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+			s.err = nil
+			return s.copy(), nil
+		}
+
+		// load report.
+		if err == nil {
+			_, span := tracer.Start(ctx, "report.load", trace.WithAttributes(attribute.String("goat.report.path", target)))
+			report, err = unmarshalReport(ctx, target)
+			endSpan(span, err)
+		}
+	}
+	if err == nil {
+		if s.target != "" && target != s.target {
+			log.Printf("report path %s now points to %s", s.path, target)
+		}
+		if err := recordHistory(ctx, report, s.path); err != nil {
+			log.Printf("failed to record report: %s", err)
+		}
+		if s.report != nil && report.ID != s.report.ID {
+			reportUpdates.publish(s, ReportUpdate{ReportID: report.ID, StartupTimeMs: millis(report.Timeline.Duration()), LoadedAt: time.Now()})
+		}
+		s.report, s.loadedAt, s.target, s.err = report, time.Now(), target, nil
+		if info != nil {
+			s.modTime, s.size = info.ModTime(), info.Size()
+		}
+		return &ServedReport{StartupReport: report}, nil
+	}
+
+	// fall back to the last good copy, unless the request is gone.
+	if s.report == nil || ctx.Err() != nil {
+		return nil, err
+	}
+	log.Printf("failed to reload report, serving copy from %s: %s", s.loadedAt.Format(time.RFC3339), err)
+	s.err = err
+	return s.copy(), nil
+}
+
+// copy returns the last successfully parsed report, marked stale if the last
+// load failed. It must be called with s locked.
+func (s *servedSource) copy() *ServedReport {
+	report := &ServedReport{StartupReport: s.report}
+	if s.err != nil {
+		report.Stale = &Staleness{LoadedAt: s.loadedAt, Err: s.err}
+	}
+	return report
+}
+
+// pollServedReport re-resolves and reloads the served report on an interval,
+// so a rotated report is picked up without waiting for a request.
+func pollServedReport(interval time.Duration) {
+	for range time.Tick(interval) {
+		switch {
+		case servingSeveral():
+			for _, s := range reportSources() {
+				s.reload(context.Background())
+			}
+		case snapshotPages:
+			lastGood.reload(context.Background())
+			refreshSnapshot(context.Background())
+		default:
+			lastGood.reload(context.Background())
+		}
+	}
+}
+
+// setWarningHeaders flags responses built from a stale or truncated report
+// with warning headers.
+func setWarningHeaders(w http.ResponseWriter, report *ServedReport) {
+	if report.Stale != nil {
+		w.Header().Add("Warning", fmt.Sprintf(`110 goat "report is stale, loaded at %s"`, report.Stale.LoadedAt.Format(time.RFC3339)))
+	}
+	if t := report.Truncation; t != nil {
+		w.Header().Add("Warning", fmt.Sprintf(`199 goat "report truncated to %d of %d events"`, t.Kept, t.Total))
+	}
+}
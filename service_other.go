@@ -0,0 +1,50 @@
+//go:build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// daemonEnv marks the detached copy of the process.
+const daemonEnv = "GOAT_DAEMON"
+
+// daemonize starts a detached copy of the process in a new session and exits.
+// In the copy, it does nothing.
+func daemonize() {
+	if os.Getenv(daemonEnv) == "1" {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("failed to daemonize: %s", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), daemonEnv+"=1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		log.Fatalf("failed to daemonize: %s", err)
+	}
+	log.Printf("goat started in the background with pid %d", cmd.Process.Pid)
+	os.Exit(0)
+}
+
+// processRunning reports whether a process with the pid exists.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// runService implements the service command, which is only available on
+// windows.
+func runService(args []string) {
+	log.Fatal("goat service is only supported on windows; use -daemon and -pid-file instead.")
+}
+
+// runAsService reports whether the process was started as a windows service.
+func runAsService() bool {
+	return false
+}
@@ -0,0 +1,163 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// daemonize is not supported on windows, where goat runs as a service.
+func daemonize() {
+	log.Fatal("-daemon is not supported on windows; use goat service install instead.")
+}
+
+// processRunning reports whether a process with the pid exists.
+func processRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	return windows.GetExitCodeProcess(h, &code) == nil && code == 259 // STILL_ACTIVE.
+}
+
+// runService implements the service command: it installs, starts, stops and
+// removes the goat windows service. Flags after the action are the server
+// flags the service runs with.
+func runService(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("service", flag.ExitOnError)
+	name := fs.String("name", "goat", "service name.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: goat service [flags] install|start|stop|remove [server flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// check configs.
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	// connect to the service manager.
+	m, err := mgr.Connect()
+	if err != nil {
+		log.Fatalf("failed to connect to service manager: %s", err)
+	}
+	defer m.Disconnect()
+
+	// run action.
+	switch action := fs.Arg(0); action {
+	case "install":
+		exe, err := os.Executable()
+		if err != nil {
+			log.Fatalf("failed to install service: %s", err)
+		}
+		exe, err = filepath.Abs(exe)
+		if err != nil {
+			log.Fatalf("failed to install service: %s", err)
+		}
+		s, err := m.CreateService(*name, exe, mgr.Config{
+			DisplayName: *name,
+			Description: "goat spring actuator startup report server.",
+			StartType:   mgr.StartAutomatic,
+		}, fs.Args()[1:]...)
+		if err != nil {
+			log.Fatalf("failed to install service: %s", err)
+		}
+		s.Close()
+	case "start":
+		s, err := m.OpenService(*name)
+		if err != nil {
+			log.Fatalf("failed to open service: %s", err)
+		}
+		defer s.Close()
+		if err := s.Start(); err != nil {
+			log.Fatalf("failed to start service: %s", err)
+		}
+	case "stop":
+		s, err := m.OpenService(*name)
+		if err != nil {
+			log.Fatalf("failed to open service: %s", err)
+		}
+		defer s.Close()
+		if _, err := s.Control(svc.Stop); err != nil {
+			log.Fatalf("failed to stop service: %s", err)
+		}
+	case "remove":
+		s, err := m.OpenService(*name)
+		if err != nil {
+			log.Fatalf("failed to open service: %s", err)
+		}
+		defer s.Close()
+		if err := s.Delete(); err != nil {
+			log.Fatalf("failed to remove service: %s", err)
+		}
+	default:
+		log.Fatalf("unknown service action: %s", action)
+	}
+}
+
+// runAsService runs the server as a windows service when started by the
+// service manager, and reports whether it did.
+func runAsService() bool {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		log.Fatalf("failed to detect windows service: %s", err)
+	}
+	if !isService {
+		return false
+	}
+	if err := svc.Run("", windowsService{}); err != nil {
+		log.Fatalf("failed to run service: %s", err)
+	}
+	return true
+}
+
+// windowsService runs the server under the service manager.
+type windowsService struct{}
+
+func (windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	// start server.
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- serve(ctx)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	// handle requests.
+	for {
+		select {
+		case err := <-errc:
+			log.Printf("server stopped: %s", err)
+			return false, 1
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32((10 * time.Second).Milliseconds())}
+				cancel()
+				if err := <-errc; err != nil {
+					log.Printf("failed to stop server: %s", err)
+				}
+				return false, 0
+			}
+		}
+	}
+}
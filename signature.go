@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// signature headers of pushed reports: the app pushing them and the
+// "sha256=<hex>" hmac of the body with the app shared secret.
+const (
+	appHeader       = "X-Goat-App"
+	signatureHeader = "X-Goat-Signature"
+)
+
+// streamSecrets are the shared secrets of the apps allowed to push reports, by
+// app name. Pushes are not verified when nil.
+var streamSecrets map[string][]byte
+
+// loadSecrets reads a secrets file of "app=secret" lines; blank lines and
+// lines starting with # are ignored.
+func loadSecrets(path string) (map[string][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	secrets := make(map[string][]byte)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		app, secret, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(app) == "" || secret == "" {
+			return nil, fmt.Errorf("line %d: expected app=secret", n)
+		}
+		secrets[strings.TrimSpace(app)] = []byte(secret)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, errors.New("no secret found")
+	}
+	return secrets, nil
+}
+
+// verifySignature reads the body and checks its signature against the app
+// secret. The body is returned only when the signature matches.
+func verifySignature(app, signature string, body io.Reader) ([]byte, error) {
+	secret, ok := streamSecrets[app]
+	if !ok {
+		return nil, fmt.Errorf("unknown app %q", app)
+	}
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return nil, errors.New("missing sha256 signature")
+	}
+	want, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), want) {
+		return nil, errors.New("signature mismatch")
+	}
+	return content, nil
+}
+
+// signedBody returns the body to consume; when pushes are verified, the whole
+// body is read and its signature checked first, so a signed stream is only
+// consumed once it is complete.
+func signedBody(app, signature string, body io.Reader) (io.Reader, error) {
+	if streamSecrets == nil {
+		return body, nil
+	}
+	content, err := verifySignature(app, signature, body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(content), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// pageSnapshot holds the pre-rendered report page in snapshot mode.
+var pageSnapshot struct {
+	sync.Mutex
+	report *StartupReport
+	stale  bool
+	page   []byte
+}
+
+// snapshotReportPage returns the pre-rendered page of the report, rendering it
+// again only when the report changed since the last render.
+func snapshotReportPage(ctx context.Context, report *ServedReport) ([]byte, error) {
+	pageSnapshot.Lock()
+	defer pageSnapshot.Unlock()
+	if pageSnapshot.page != nil && pageSnapshot.report == report.StartupReport && pageSnapshot.stale == (report.Stale != nil) {
+		return pageSnapshot.page, nil
+	}
+
+	page, err := renderReportPage(ctx, report, defaultPageOptions)
+	if err != nil {
+		return nil, err
+	}
+	pageSnapshot.report, pageSnapshot.stale, pageSnapshot.page = report.StartupReport, report.Stale != nil, page
+	return page, nil
+}
+
+// refreshSnapshot loads the served report and pre-renders its page if it changed.
+func refreshSnapshot(ctx context.Context) {
+	report, err := loadServedReport(ctx)
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		return
+	}
+	if _, err := snapshotReportPage(ctx, report); err != nil {
+		log.Printf("failed to render template: %s", err)
+	}
+}
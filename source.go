@@ -0,0 +1,166 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// stdinSource is the report source reading the report from stdin.
+const stdinSource = "-"
+
+// stdinReport is the report read from stdin, which can only be read once.
+var stdinReport struct {
+	once   sync.Once
+	report *StartupReport
+	err    error
+}
+
+// readStdinReport reads the report from stdin on the first call, and returns
+// the same report afterwards.
+func readStdinReport() (*StartupReport, error) {
+	stdinReport.once.Do(func() {
+		content, err := readReportContent(os.Stdin)
+		if err != nil {
+			stdinReport.err = err
+			return
+		}
+		// stdin is shared by every request, so none of them cancels it.
+		stdinReport.report, stdinReport.err = decodeReport(context.Background(), content)
+	})
+	return stdinReport.report, stdinReport.err
+}
+
+// stdinPiped reports whether stdin is a pipe or a file rather than a terminal.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// isURL reports whether the report source is a remote url.
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// isReportFile reports whether the file name looks like a report.
+func isReportFile(name string) bool {
+	return strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz") || strings.HasSuffix(name, ".zip")
+}
+
+// loadReport loads a report from any supported source: a url, stdin, a plain
+// json, gzip or zip file, or a directory, in which case its newest report is
+// used.
+func loadReport(ctx context.Context, source string) (*StartupReport, error) {
+	if source == stdinSource {
+		return readStdinReport()
+	}
+	if isURL(source) {
+		return fetchReport(ctx, source)
+	}
+	path, err := resolveReportFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalReport(ctx, path)
+}
+
+// resolveReportFile resolves symlinks in the path and, for directories, picks
+// the most recently modified report file inside.
+func resolveReportFile(path string) (string, error) {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return path, err
+	}
+
+	// find newest report.
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var newest string
+	var newestInfo os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !isReportFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if newestInfo == nil || info.ModTime().After(newestInfo.ModTime()) {
+			newest, newestInfo = entry.Name(), info
+		}
+	}
+	if newest == "" {
+		return "", fmt.Errorf("no report found in directory %s", path)
+	}
+	return filepath.EvalSymlinks(filepath.Join(path, newest))
+}
+
+// decodeReport parses a report, detecting gzip and zip compressed content.
+// Only one level is decompressed: an archive nested inside another archive is
+// rejected rather than unpacked recursively.
+func decodeReport(ctx context.Context, reportContent []byte) (*StartupReport, error) {
+	content, err := decompressReport(reportContent)
+	if err != nil {
+		return nil, err
+	}
+	if isArchive(content) {
+		return nil, errors.New("nested archives are not supported")
+	}
+	return parseReport(ctx, content)
+}
+
+// isArchive reports whether the content starts like a gzip or zip archive.
+func isArchive(content []byte) bool {
+	return bytes.HasPrefix(content, []byte{0x1f, 0x8b}) || bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
+
+// decompressReport returns the content of a gzip or zip compressed report, or
+// the content itself when it isn't compressed.
+func decompressReport(reportContent []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(reportContent, []byte{0x1f, 0x8b}):
+		r, err := gzip.NewReader(bytes.NewReader(reportContent))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readReportContent(r)
+	case bytes.HasPrefix(reportContent, []byte("PK\x03\x04")):
+		return unzipReport(reportContent)
+	default:
+		return reportContent, nil
+	}
+}
+
+// unzipReport returns the content of the first json file in the zip archive.
+func unzipReport(archive []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return readReportContent(r)
+	}
+	return nil, errors.New("no json report found in zip archive")
+}
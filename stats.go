@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// start classes of reports; cold and warm startup times don't mix in the same
+// percentiles.
+const (
+	StartCold      = "cold"
+	StartWarm      = "warm"
+	StartUnlabeled = "unlabeled"
+)
+
+// DirStats represents the aggregated startup times of a set of reports.
+type DirStats struct {
+	Reports   int        `json:"reports"`
+	MinMs     float64    `json:"minMs"`
+	AvgMs     float64    `json:"avgMs"`
+	MaxMs     float64    `json:"maxMs"`
+	P50Ms     float64    `json:"p50Ms"`
+	P90Ms     float64    `json:"p90Ms"`
+	P99Ms     float64    `json:"p99Ms"`
+	SlowSteps []SlowStep `json:"slowSteps"`
+
+	// ByStart are the stats of each start class, when any report is labeled.
+	ByStart map[string]DirStats `json:"byStart,omitempty"`
+}
+
+// SlowStep represents a step that is among the slowest of several reports.
+type SlowStep struct {
+	Key   string  `json:"key"`
+	Count int     `json:"count"`
+	AvgMs float64 `json:"avgMs"`
+}
+
+// runStats implements the stats command: it aggregates all the reports of a
+// directory. Reports labeled cold or warm in their file names are also
+// aggregated by start class.
+func runStats(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text or json.")
+	top := fs.Int("top", 5, "number of slowest steps of each report counted as slow.")
+	limit := fs.Int("limit", 10, "number of most common slow steps printed.")
+	registerParseFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: goat stats [flags] <directory>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// check configs.
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("unsupported stats format: %s", *format)
+	}
+
+	// get reports.
+	entries, err := os.ReadDir(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("failed to read directory: %s", err)
+	}
+	var reports []*StartupReport
+	var classes []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isReportFile(entry.Name()) {
+			continue
+		}
+		report, err := loadReport(context.Background(), filepath.Join(fs.Arg(0), entry.Name()))
+		if err != nil {
+			log.Printf("failed to load report %s: %s", entry.Name(), err)
+			continue
+		}
+		reports = append(reports, report)
+		classes = append(classes, startClass(entry.Name()))
+	}
+	if len(reports) == 0 {
+		log.Fatalf("no report found in directory %s", fs.Arg(0))
+	}
+
+	// write stats.
+	s := dirStats(reports, *top, *limit)
+	if byStart := classStats(reports, classes, *top, *limit); byStart != nil {
+		s.ByStart = byStart
+	}
+	if *format == "json" {
+		if err := encodeJSON(os.Stdout, s); err != nil {
+			log.Fatalf("failed to write json: %s", err)
+		}
+		return
+	}
+	printStats(os.Stdout, s)
+}
+
+// dirStats aggregates the reports. The top slowest steps of every report are
+// counted, and the limit most common of them are kept.
+func dirStats(reports []*StartupReport, top, limit int) DirStats {
+	// totals.
+	totals := make([]time.Duration, len(reports))
+	var sum time.Duration
+	for i, r := range reports {
+		totals[i] = r.Timeline.Duration()
+		sum += totals[i]
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i] < totals[j]
+	})
+	s := DirStats{
+		Reports:   len(reports),
+		MinMs:     millis(totals[0]),
+		AvgMs:     millis(sum / time.Duration(len(totals))),
+		MaxMs:     millis(totals[len(totals)-1]),
+		P50Ms:     millis(percentile(totals, 50)),
+		P90Ms:     millis(percentile(totals, 90)),
+		P99Ms:     millis(percentile(totals, 99)),
+		SlowSteps: []SlowStep{},
+	}
+
+	// slow steps.
+	counts := make(map[string]int)
+	durations := make(map[string]time.Duration)
+	var keys []string
+	for _, r := range reports {
+		seen := make(map[string]bool)
+		for _, e := range r.Timeline.Slowest(top) {
+			key := e.StartupStep.Key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if counts[key] == 0 {
+				keys = append(keys, key)
+			}
+			counts[key]++
+			durations[key] += e.Duration()
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return durations[keys[i]]/time.Duration(counts[keys[i]]) > durations[keys[j]]/time.Duration(counts[keys[j]])
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	for _, key := range keys {
+		s.SlowSteps = append(s.SlowSteps, SlowStep{Key: key, Count: counts[key], AvgMs: millis(durations[key] / time.Duration(counts[key]))})
+	}
+	return s
+}
+
+// startClass returns the start class the report file name is labeled with,
+// e.g. report-cold.json, or StartUnlabeled.
+func startClass(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if word == StartCold || word == StartWarm {
+			return word
+		}
+	}
+	return StartUnlabeled
+}
+
+// classStats aggregates the reports of each start class apart. It returns nil
+// when no report is labeled.
+func classStats(reports []*StartupReport, classes []string, top, limit int) map[string]DirStats {
+	byClass := make(map[string][]*StartupReport)
+	for i, r := range reports {
+		byClass[classes[i]] = append(byClass[classes[i]], r)
+	}
+	if len(byClass[StartUnlabeled]) == len(reports) {
+		return nil
+	}
+	stats := make(map[string]DirStats)
+	for class, rs := range byClass {
+		stats[class] = dirStats(rs, top, limit)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (p*len(sorted)+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// printStats prints the aggregated startup times and the most common slow steps.
+func printStats(w io.Writer, s DirStats) {
+	ms := func(v float64) string {
+		return formatDuration(time.Duration(v * float64(time.Millisecond)))
+	}
+	fmt.Fprintf(w, "REPORTS: %d\n\n", s.Reports)
+	fmt.Fprintf(w, "STARTUP TIME:\n")
+	fmt.Fprintf(w, "  min %s  avg %s  max %s\n", ms(s.MinMs), ms(s.AvgMs), ms(s.MaxMs))
+	fmt.Fprintf(w, "  p50 %s  p90 %s  p99 %s\n", ms(s.P50Ms), ms(s.P90Ms), ms(s.P99Ms))
+
+	// start classes.
+	for _, class := range []string{StartCold, StartWarm, StartUnlabeled} {
+		c, ok := s.ByStart[class]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s STARTS (%d):\n", strings.ToUpper(class), c.Reports)
+		fmt.Fprintf(w, "  min %s  avg %s  max %s\n", ms(c.MinMs), ms(c.AvgMs), ms(c.MaxMs))
+		fmt.Fprintf(w, "  p50 %s  p90 %s  p99 %s\n", ms(c.P50Ms), ms(c.P90Ms), ms(c.P99Ms))
+	}
+
+	fmt.Fprintf(w, "\nMOST COMMON SLOW STEPS:\n")
+	for _, step := range s.SlowSteps {
+		fmt.Fprintf(w, "  %3d/%d  avg %10s  %s\n", step.Count, s.Reports, ms(step.AvgMs), step.Key)
+	}
+}
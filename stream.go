@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// streamLine represents one NDJSON line of a streamed report. Lines carrying a
+// startupStep are events; other lines may set the report header fields.
+type streamLine struct {
+	SpringBootVersion string       `json:"springBootVersion"`
+	StartupStep       *StartupStep `json:"startupStep"`
+	StartTime         time.Time    `json:"startTime"`
+	EndTime           time.Time    `json:"endTime"`
+}
+
+// liveReport is a report built incrementally from streamed events.
+type liveReport struct {
+	mu        sync.Mutex
+	report    StartupReport
+	startTime bool // whether the timeline start time was set explicitly.
+
+	// with -max-events, the events compete for a place under the cap as in
+	// decodeCapped instead of being appended to the report.
+	kept  eventHeap
+	total int
+
+	// last snapshot, reused until the report changes.
+	snap *StartupReport
+}
+
+// live is the streamed report served in stream mode, nil otherwise.
+var live *liveReport
+
+// snapshot returns a copy of the report built so far. The same copy is
+// returned until new lines are added.
+func (l *liveReport) snapshot() *StartupReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.snap == nil {
+		report := l.report
+		report.Timeline.Events = append([]Events(nil), l.report.Timeline.Events...)
+		if maxEvents > 0 {
+			kept := append(eventHeap(nil), l.kept...)
+			sort.Slice(kept, func(i, j int) bool {
+				return kept[i].index < kept[j].index
+			})
+			for _, e := range kept {
+				report.Timeline.Events = append(report.Timeline.Events, e.Events)
+			}
+			if l.total > len(kept) {
+				report.Truncation = &Truncation{Total: l.total, Kept: len(kept)}
+			}
+		}
+		l.snap = &report
+	}
+	return l.snap
+}
+
+// add adds a streamed line to the report.
+func (l *liveReport) add(line streamLine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snap = nil
+
+	// header line.
+	if line.StartupStep == nil {
+		if line.SpringBootVersion != "" {
+			l.report.SpringBootVersion = line.SpringBootVersion
+		}
+		if !line.StartTime.IsZero() {
+			l.report.Timeline.StartTime = line.StartTime
+			l.startTime = true
+		}
+		return
+	}
+
+	// event line; the timeline starts with the earliest event unless told otherwise.
+	e := Events{StartupStep: *line.StartupStep, StartTime: line.StartTime, EndTime: line.EndTime}
+	if !l.startTime && (l.report.Timeline.StartTime.IsZero() || e.StartTime.Before(l.report.Timeline.StartTime)) {
+		l.report.Timeline.StartTime = e.StartTime
+	}
+	l.total++
+	if maxEvents <= 0 {
+		l.report.Timeline.Events = append(l.report.Timeline.Events, e)
+		return
+	}
+	heap.Push(&l.kept, cappedEvent{Events: e, index: l.total - 1})
+	if l.kept.Len() > maxEvents {
+		heap.Pop(&l.kept)
+	}
+}
+
+// consume reads NDJSON lines from r until EOF and returns how many were added.
+func (l *liveReport) consume(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var line streamLine
+		if err := unmarshalJSON(scanner.Bytes(), &line); err != nil {
+			return n, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		l.add(line)
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// consumeStdin streams events from stdin when it is a pipe.
+func (l *liveReport) consumeStdin() {
+	if !stdinPiped() {
+		return
+	}
+	go func() {
+		n, err := l.consume(os.Stdin)
+		if err != nil {
+			log.Printf("failed to read streamed events: %s", err)
+		}
+		log.Printf("stdin stream closed after %d lines", n)
+	}()
+}
+
+func handleStream(w http.ResponseWriter, r *http.Request) {
+	// streams outlast the server read and write timeouts.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		log.Printf("failed to clear stream read deadline: %s", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("failed to clear stream write deadline: %s", err)
+	}
+
+	// consume events; signed streams are buffered, as the signature covers
+	// the whole body.
+	if maxReportSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxReportSize)
+	}
+	body, err := signedBody(r.Header.Get(appHeader), r.Header.Get(signatureHeader), r.Body)
+	if err != nil {
+		log.Printf("failed to verify streamed events: %s", err)
+		http.Error(w, err.Error(), bodyErrorStatus(err, http.StatusUnauthorized))
+		return
+	}
+	n, err := live.consume(body)
+	if err != nil {
+		log.Printf("failed to read streamed events: %s", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, map[string]int{"lines": n})
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AutoConfigCost represents the initialization cost of an auto-configuration class.
+type AutoConfigCost struct {
+	Class    string
+	Duration time.Duration
+}
+
+// autoConfigClass returns the auto-configuration class a bean step belongs to,
+// or "" if the step is not about an auto-configuration.
+func autoConfigClass(s StartupStep) string {
+	class := s.Tag("beanType")
+	if class == "" {
+		class = s.Tag("beanName")
+	}
+
+	// nested configurations are excluded through their enclosing class.
+	class, _, _ = strings.Cut(class, "$")
+	if !strings.Contains(class, ".autoconfigure.") || !strings.HasSuffix(class, "AutoConfiguration") {
+		return ""
+	}
+	return class
+}
+
+// autoConfigCosts returns the auto-configurations that took at least min to
+// initialize, slowest first.
+func autoConfigCosts(t Timeline, min time.Duration) []AutoConfigCost {
+	// sum durations by class.
+	durations := make(map[string]time.Duration)
+	for _, e := range t.Events {
+		if class := autoConfigClass(e.StartupStep); class != "" {
+			durations[class] += e.Duration()
+		}
+	}
+
+	var costs []AutoConfigCost
+	for class, d := range durations {
+		if d >= min {
+			costs = append(costs, AutoConfigCost{Class: class, Duration: d})
+		}
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Duration != costs[j].Duration {
+			return costs[i].Duration > costs[j].Duration
+		}
+		return costs[i].Class < costs[j].Class
+	})
+	return costs
+}
+
+// writeAutoConfigExclude writes a spring.autoconfigure.exclude property listing
+// the given auto-configurations.
+func writeAutoConfigExclude(w io.Writer, costs []AutoConfigCost, min time.Duration) {
+	fmt.Fprintf(w, "# auto-configurations that took at least %s to initialize.\n", min)
+	fmt.Fprintf(w, "# keep only the ones your application does not use before applying.\n")
+	if len(costs) == 0 {
+		fmt.Fprintf(w, "# no candidates found.\n")
+		return
+	}
+	for _, c := range costs {
+		fmt.Fprintf(w, "#   %s: %s\n", c.Class, formatDuration(c.Duration))
+	}
+	fmt.Fprintf(w, "spring.autoconfigure.exclude=")
+	for i, c := range costs {
+		if i > 0 {
+			fmt.Fprintf(w, ",\\\n  ")
+		}
+		fmt.Fprint(w, c.Class)
+	}
+	fmt.Fprintln(w)
+}
+
+// BeanCost represents the instantiation cost of a bean.
+type BeanCost struct {
+	StepID   int
+	Name     string
+	Duration time.Duration
+
+	// SelfTime is the instantiation time not spent on the dependencies
+	// instantiated under the bean.
+	SelfTime time.Duration
+}
+
+// slowBeans returns the beans that took at least min to instantiate
+// themselves, slowest first. Beans are ranked by self time, so the time of a
+// slow dependency is only counted against the dependency.
+func slowBeans(t Timeline, min time.Duration) []BeanCost {
+	var beans []BeanCost
+	buildTree(t).Walk(func(n *Node) bool {
+		e := n.Event
+		name := e.StartupStep.Tag("beanName")
+		if e.StartupStep.Name != "spring.beans.instantiate" || name == "" || autoConfigClass(e.StartupStep) != "" {
+			return true
+		}
+		if n.SelfTime >= min {
+			beans = append(beans, BeanCost{StepID: e.StartupStep.ID, Name: name, Duration: e.Duration(), SelfTime: n.SelfTime})
+		}
+		return true
+	})
+	sort.SliceStable(beans, func(i, j int) bool {
+		return beans[i].SelfTime > beans[j].SelfTime
+	})
+	return beans
+}
+
+// usesJMX reports whether the timeline shows JMX infrastructure being set up.
+func usesJMX(t Timeline) bool {
+	for _, e := range t.Events {
+		class := e.StartupStep.Tag("beanType") + " " + e.StartupStep.Tag("beanName")
+		if strings.Contains(class, ".jmx.") || strings.Contains(class, "mbeanExporter") {
+			return true
+		}
+	}
+	return false
+}
+
+// writePropertiesSnippet writes a suggested application.properties snippet
+// built from the report findings.
+func writePropertiesSnippet(w io.Writer, t Timeline, beanMin, autoConfigMin time.Duration) {
+	fmt.Fprintf(w, "# application.properties tuning suggested by goat.\n")
+	fmt.Fprintf(w, "# review each entry before applying it.\n\n")
+
+	// banner.
+	fmt.Fprintf(w, "# skip printing the banner.\n")
+	fmt.Fprintf(w, "spring.main.banner-mode=off\n\n")
+
+	// jmx.
+	if usesJMX(t) {
+		fmt.Fprintf(w, "# jmx infrastructure is initialized during startup; disable it if nothing reads the mbeans.\n")
+		fmt.Fprintf(w, "spring.jmx.enabled=false\n\n")
+	}
+
+	// lazy initialization.
+	if beans := slowBeans(t, beanMin); len(beans) > 0 {
+		fmt.Fprintf(w, "# beans that took at least %s to instantiate, excluding their dependencies, are lazy-init candidates:\n", beanMin)
+		for _, b := range beans {
+			fmt.Fprintf(w, "#   %s: %s\n", b.Name, formatDuration(b.SelfTime))
+		}
+		fmt.Fprintf(w, "# mark them @Lazy, or enable lazy initialization globally:\n")
+		fmt.Fprintf(w, "#spring.main.lazy-initialization=true\n\n")
+	}
+
+	// exclusions.
+	writeAutoConfigExclude(w, autoConfigCosts(t, autoConfigMin), autoConfigMin)
+}
+
+// durationParam returns the duration query parameter with the given name, or def if absent.
+func durationParam(r *http.Request, name string, def time.Duration) (time.Duration, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s duration: %w", name, err)
+	}
+	return d, nil
+}
+
+func handleAutoConfigExclude(w http.ResponseWriter, r *http.Request) {
+	// get threshold.
+	min, err := durationParam(r, "min", 50*time.Millisecond)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write property.
+	setWarningHeaders(w, report)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writeAutoConfigExclude(w, autoConfigCosts(report.Timeline, min), min)
+}
+
+func handlePropertiesSnippet(w http.ResponseWriter, r *http.Request) {
+	// get thresholds.
+	beanMin, err := durationParam(r, "beanMin", 200*time.Millisecond)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	autoConfigMin, err := durationParam(r, "min", 50*time.Millisecond)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write snippet.
+	setWarningHeaders(w, report)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	writePropertiesSnippet(w, report.Timeline, beanMin, autoConfigMin)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Summarizer produces a short natural-language summary from a prompt
+// describing a report or a comparison.
+type Summarizer interface {
+	Summarize(ctx context.Context, prompt string) (string, error)
+}
+
+// summarizerConfig holds the summarizer flags.
+type summarizerConfig struct {
+	url   string
+	model string
+}
+
+// register registers the summarizer flags on the flag set.
+func (c *summarizerConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.url, "summarizer-url", "", "openai-compatible api base url (e.g. https://api.openai.com/v1) used to summarize reports. the api key is read from GOAT_SUMMARIZER_API_KEY.")
+	fs.StringVar(&c.model, "summarizer-model", "gpt-4o-mini", "model used to summarize reports.")
+}
+
+// summarizer returns the configured summarizer, or nil if none is configured.
+func (c summarizerConfig) summarizer() Summarizer {
+	if c.url == "" {
+		return nil
+	}
+	return &cachedSummarizer{next: &openAISummarizer{
+		url:    strings.TrimSuffix(c.url, "/") + "/chat/completions",
+		model:  c.model,
+		apiKey: os.Getenv("GOAT_SUMMARIZER_API_KEY"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}}
+}
+
+// openAISummarizer summarizes through an openai-compatible chat completions api.
+type openAISummarizer struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+// Summarize implements Summarizer.
+func (s *openAISummarizer) Summarize(ctx context.Context, prompt string) (string, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model": s.model,
+		"messages": []message{
+			{Role: "system", Content: "You summarize Spring Boot startup reports for engineers. Answer with one or two short sentences, naming the steps that matter most and their durations."},
+			{Role: "user", Content: prompt},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// call api.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("summarizer returned %s", resp.Status)
+	}
+
+	// get answer.
+	var completion struct {
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("summarizer returned no choices")
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
+
+// maxCachedSummaries is the number of summaries kept by cachedSummarizer.
+const maxCachedSummaries = 100
+
+// cachedSummarizer remembers the summaries by prompt so unchanged reports are
+// not summarized again on every request. The upstream summarizer is called
+// without the lock held, so a slow summary doesn't hold up the others.
+type cachedSummarizer struct {
+	next Summarizer
+
+	mu        sync.Mutex
+	summaries map[string]string
+	order     []string // prompts, oldest first.
+}
+
+// Summarize implements Summarizer.
+func (s *cachedSummarizer) Summarize(ctx context.Context, prompt string) (string, error) {
+	s.mu.Lock()
+	summary, hit := s.summaries[prompt]
+	s.mu.Unlock()
+	stats.observeSummaryCache(hit)
+	if hit {
+		return summary, nil
+	}
+	summary, err := s.next.Summarize(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	// cache summary, dropping the oldest ones.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.summaries == nil {
+		s.summaries = make(map[string]string)
+	}
+	if _, ok := s.summaries[prompt]; !ok {
+		s.order = append(s.order, prompt)
+	}
+	s.summaries[prompt] = summary
+	for len(s.order) > maxCachedSummaries {
+		delete(s.summaries, s.order[0])
+		s.order = s.order[1:]
+	}
+	return summary, nil
+}
+
+// reportPrompt describes a report for the summarizer.
+func reportPrompt(ctx context.Context, report *StartupReport) (string, error) {
+	a, err := analyze(ctx, report)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Spring Boot %s application started in %s.\n", report.SpringBootVersion, formatDuration(report.Timeline.Duration()))
+	if m := formatMilestones(report.Timeline.Milestones()); m != "" {
+		fmt.Fprintf(&b, "Milestones: %s. Readiness is what matters most.\n", m)
+	}
+	fmt.Fprintf(&b, "Slowest steps:\n")
+	for _, e := range report.Timeline.Slowest(10) {
+		fmt.Fprintf(&b, "- %s: %s\n", e.StartupStep.Key(), formatDuration(e.Duration()))
+	}
+	fmt.Fprintf(&b, "Findings:\n")
+	for _, f := range a.Findings {
+		fmt.Fprintf(&b, "- [%s] %s\n", f.Severity, f.Message)
+	}
+	return b.String(), nil
+}
+
+// comparisonPrompt describes a comparison for the summarizer.
+func comparisonPrompt(c Comparison, threshold time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Startup time went from %s to %s (%s).\n", formatDuration(c.Base.Timeline.Duration()), formatDuration(c.Head.Timeline.Duration()), formatDelta(c.TotalDelta(), c.Base.Timeline.Duration()))
+	list := func(title string, steps []StepDiff) {
+		fmt.Fprintf(&b, "%s:\n", title)
+		for i, d := range steps {
+			if i == 10 {
+				break
+			}
+			fmt.Fprintf(&b, "- %s (%s): %s -> %s\n", d.Key, d.Status, formatDuration(d.Base), formatDuration(d.Head))
+		}
+	}
+	list("Regressions", c.Regressions(threshold))
+	list("Improvements", c.Improvements(threshold))
+	list("New steps", c.WithStatus(StepAdded))
+	list("Removed steps", c.WithStatus(StepRemoved))
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"time"
+)
+
+// svg layout configs.
+const (
+	svgWidth      = 800
+	svgLabelWidth = 320
+	svgRowHeight  = 18
+	svgRowGap     = 4
+	svgPadding    = 8
+)
+
+// svgColors maps the duration css classes to svg fill colors.
+var svgColors = map[string]string{
+	"badge-success": "green",
+	"badge-warning": "orange",
+	"badge-danger":  "red",
+}
+
+// renderTimelineSVG renders a compact horizontal timeline of the top-level steps.
+func renderTimelineSVG(t Timeline) []byte {
+	roots := t.RootEvents()
+	total := t.Duration()
+	barsWidth := float64(svgWidth - svgLabelWidth - 2*svgPadding)
+	height := 2*svgPadding + len(roots)*(svgRowHeight+svgRowGap) + svgRowHeight
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`, svgWidth, height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="white"/>`)
+
+	for i, e := range roots {
+		y := svgPadding + i*(svgRowHeight+svgRowGap)
+
+		// bar position relative to the timeline start.
+		var x, w float64
+		if total > 0 {
+			x = barsWidth * float64(e.StartTime.Sub(t.StartTime)) / float64(total)
+			w = barsWidth * float64(e.Duration()) / float64(total)
+		}
+		if w < 1 {
+			w = 1
+		}
+
+		fmt.Fprintf(&buf, `<text x="%d" y="%d">%s</text>`, svgPadding, y+svgRowHeight-5, html.EscapeString(e.StartupStep.Name))
+		fmt.Fprintf(&buf, `<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="3" fill="%s"><title>%s: %s</title></rect>`,
+			float64(svgLabelWidth+svgPadding)+x, y, w, svgRowHeight, svgColors[classBasedOnDuration(e.Duration())],
+			html.EscapeString(e.StartupStep.Name), e.Duration().Round(time.Millisecond))
+	}
+
+	// total startup time.
+	y := svgPadding + len(roots)*(svgRowHeight+svgRowGap)
+	fmt.Fprintf(&buf, `<text x="%d" y="%d" font-weight="bold">STARTUP TIME: %s</text>`, svgPadding, y+svgRowHeight-5, total.Round(time.Millisecond))
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+// badge layout configs.
+const (
+	badgeHeight    = 20
+	badgeCharWidth = 7
+	badgePadding   = 6
+)
+
+// renderBadgeSVG renders a badge with the total startup time.
+func renderBadgeSVG(t Timeline) []byte {
+	label, value := "startup", formatDuration(t.Duration())
+	labelWidth := len(label)*badgeCharWidth + 2*badgePadding
+	valueWidth := len(value)*badgeCharWidth + 2*badgePadding
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`, labelWidth+valueWidth, badgeHeight)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#555"/>`, labelWidth, badgeHeight)
+	fmt.Fprintf(&buf, `<rect x="%d" width="%d" height="%d" fill="%s"/>`, labelWidth, valueWidth, badgeHeight, svgColors[classBasedOnDuration(t.Duration())])
+	fmt.Fprintf(&buf, `<text x="%d" y="14" fill="white">%s</text>`, badgePadding, label)
+	fmt.Fprintf(&buf, `<text x="%d" y="14" fill="white">%s</text>`, labelWidth+badgePadding, value)
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+func handleTimelineSVG(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	r, ok := withReportParam(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// set svg content type.
+	setWarningHeaders(w, report)
+	w.Header().Set("Content-Type", "image/svg+xml")
+
+	// render image.
+	if _, err := w.Write(renderTimelineSVG(report.Timeline)); err != nil {
+		log.Printf("failed to write timeline image: %s", err)
+	}
+}
+
+func handleBadgeSVG(w http.ResponseWriter, r *http.Request) {
+	// get report.
+	r, ok := withReportParam(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// set svg content type.
+	setWarningHeaders(w, report)
+	w.Header().Set("Content-Type", "image/svg+xml")
+
+	// render image.
+	if _, err := w.Write(renderBadgeSVG(report.Timeline)); err != nil {
+		log.Printf("failed to write badge image: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies goat's own telemetry.
+const instrumentationName = "github.com/corabank/goat"
+
+var (
+	tracer = otel.Tracer(instrumentationName)
+	meter  = otel.Meter(instrumentationName)
+)
+
+// telemetryConfig holds the opentelemetry flags.
+type telemetryConfig struct {
+	endpoint    string
+	serviceName string
+}
+
+// register registers the telemetry flags on the flag set.
+func (c *telemetryConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.endpoint, "otel-endpoint", "", "otlp/http collector url (e.g. http://localhost:4318) receiving goat's own traces and metrics. disabled if empty.")
+	fs.StringVar(&c.serviceName, "otel-service-name", "goat", "service name reported in goat's own telemetry.")
+}
+
+// setup installs the global trace and metric providers exporting to the
+// configured endpoint. It returns a function flushing and stopping them.
+func (c telemetryConfig) setup(ctx context.Context) (func(context.Context) error, error) {
+	if c.endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// create exporters.
+	endpoint := strings.TrimSuffix(c.endpoint, "/")
+	traceExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"))
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(endpoint+"/v1/metrics"))
+	if err != nil {
+		return nil, err
+	}
+
+	// create providers.
+	res := resource.NewSchemaless(attribute.String("service.name", c.serviceName))
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traceExporter), sdktrace.WithResource(res))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)), sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return func(ctx context.Context) error {
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}
+
+// statusRecorder records the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// instrument wraps the handler with a server span and request metrics for the
+// route pattern.
+func instrument(pattern string, h http.Handler) http.Handler {
+	route := pattern
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		route = path
+	}
+
+	requests, _ := meter.Int64Counter("goat.http.requests", metric.WithDescription("HTTP requests served."))
+	durations, _ := meter.Float64Histogram("goat.http.duration", metric.WithDescription("HTTP request durations."), metric.WithUnit("s"))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// start span.
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		// serve.
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(rec, r.WithContext(ctx))
+
+		// record request.
+		attrs := []attribute.KeyValue{
+			attribute.String("http.route", route),
+			attribute.String("http.request.method", r.Method),
+			attribute.Int("http.response.status_code", rec.status),
+		}
+		span.SetAttributes(attrs...)
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+		requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+		durations.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+		stats.observeRequest(route, rec.status, time.Since(start))
+	})
+}
+
+// endSpan records err on the span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Page themes.
+const (
+	ThemeTerminal = "terminal"
+	ThemeLight    = "light"
+	ThemeDark     = "dark"
+)
+
+// pageTheme is the color theme of the pages, served as static/theme.css.
+var pageTheme = ThemeTerminal
+
+// registerThemeFlags registers the page theme flag on the flag set.
+func registerThemeFlags(fs *flag.FlagSet) {
+	fs.StringVar(&pageTheme, "theme", pageTheme, "color theme of the pages: terminal, light or dark.")
+}
+
+// checkTheme returns an error if the theme is unknown.
+func checkTheme(theme string) error {
+	switch theme {
+	case ThemeTerminal, ThemeLight, ThemeDark:
+		return nil
+	}
+	return fmt.Errorf("unknown theme %q, expected %s, %s or %s", theme, ThemeTerminal, ThemeLight, ThemeDark)
+}
+
+// themeFile returns the stylesheet of the page theme.
+func themeFile() string {
+	return "web/themes/" + pageTheme + ".css"
+}
+
+func handleTheme(w http.ResponseWriter, r *http.Request) {
+	style, err := files.ReadFile(themeFile())
+	if err != nil {
+		log.Printf("failed to read theme: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Write(style)
+}
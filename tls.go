@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// tlsConfig holds the tls flags. Without any, the server speaks plain http.
+type tlsConfig struct {
+	cert       string
+	key        string
+	selfSigned bool
+}
+
+// serverTLS is the tls of the listeners.
+var serverTLS tlsConfig
+
+// register registers the tls flags on the flag set.
+func (c *tlsConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.cert, "tls-cert", "", "pem certificate file the server is served with over https, with tls-key.")
+	fs.StringVar(&c.key, "tls-key", "", "pem private key file of tls-cert.")
+	fs.BoolVar(&c.selfSigned, "tls-self-signed", false, "serve https with a self-signed certificate generated on startup, for development.")
+}
+
+// enabled reports whether the server speaks https.
+func (c tlsConfig) enabled() bool {
+	return c.cert != "" || c.selfSigned
+}
+
+// check returns the errors of the tls flags.
+func (c tlsConfig) check() []error {
+	var errs []error
+	fail := func(err error) { errs = append(errs, err) }
+	switch {
+	case c.selfSigned && (c.cert != "" || c.key != ""):
+		fail(fmt.Errorf("tls-self-signed can't be set with tls-cert or tls-key"))
+	case (c.cert == "") != (c.key == ""):
+		fail(fmt.Errorf("tls-cert and tls-key are set together"))
+	case c.cert != "":
+		if _, err := tls.LoadX509KeyPair(c.cert, c.key); err != nil {
+			fail(fmt.Errorf("failed to load tls certificate: %s", err))
+		}
+	}
+	return errs
+}
+
+// listen serves the server over http or https.
+func (c tlsConfig) listen(server *http.Server) error {
+	switch {
+	case c.selfSigned:
+		cert, err := selfSignedCert()
+		if err != nil {
+			return fmt.Errorf("failed to generate self-signed certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		return server.ListenAndServeTLS("", "")
+	case c.cert != "":
+		return server.ListenAndServeTLS(c.cert, c.key)
+	}
+	return server.ListenAndServe()
+}
+
+// selfSignedCert generates a certificate valid for a year for localhost and
+// the host name.
+func selfSignedCert() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	names := []string{"localhost"}
+	if host, err := os.Hostname(); err == nil && host != "localhost" {
+		names = append(names, host)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "goat", Organization: []string{"goat development"}},
+		DNSNames:              names,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
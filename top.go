@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// TopStep represents one of the slowest steps of a report.
+type TopStep struct {
+	ID         int     `json:"id"`
+	Key        string  `json:"key"`
+	DurationMs float64 `json:"durationMs"`
+	SelfTimeMs float64 `json:"selfTimeMs"`
+}
+
+// runTop implements the top command: it prints the slowest steps of a report,
+// by duration or by self time.
+func runTop(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	path := fs.String("report", "", "spring actuator startup report, file, url or - for stdin. required!")
+	limit := fs.Int("limit", 10, "number of slowest steps printed.")
+	by := fs.String("by", "duration", "order of the steps: duration, or self for the time excluding their children.")
+	format := fs.String("format", "text", "output format: text or json.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerColorFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *path == "" {
+		log.Fatal("startup report is required!")
+	}
+	if *limit < 1 {
+		log.Fatalf("invalid top limit: %d", *limit)
+	}
+	if *by != "duration" && *by != "self" {
+		log.Fatalf("unsupported top order: %s", *by)
+	}
+	if *format != "text" && *format != "json" {
+		log.Fatalf("unsupported top format: %s", *format)
+	}
+
+	// get report.
+	report, err := loadReport(context.Background(), *path)
+	if err != nil {
+		log.Fatalf("failed to load report: %s", err)
+	}
+
+	// write steps.
+	steps := topSteps(report.Timeline, *limit, *by == "self")
+	if *format == "json" {
+		if err := encodeJSON(os.Stdout, steps); err != nil {
+			log.Fatalf("failed to write json: %s", err)
+		}
+		return
+	}
+	printTop(os.Stdout, steps)
+}
+
+// topSteps returns the n slowest steps of the timeline, slowest first, by
+// duration or by self time.
+func topSteps(t Timeline, n int, bySelf bool) []TopStep {
+	steps := []TopStep{}
+	buildTree(t).Walk(func(node *Node) bool {
+		e := node.Event
+		steps = append(steps, TopStep{
+			ID:         e.StartupStep.ID,
+			Key:        e.StartupStep.Key(),
+			DurationMs: millis(e.Duration()),
+			SelfTimeMs: millis(node.SelfTime),
+		})
+		return true
+	})
+	sort.SliceStable(steps, func(i, j int) bool {
+		if bySelf {
+			return steps[i].SelfTimeMs > steps[j].SelfTimeMs
+		}
+		return steps[i].DurationMs > steps[j].DurationMs
+	})
+	if len(steps) > n {
+		steps = steps[:n]
+	}
+	return steps
+}
+
+// printTop prints the steps with their duration and self time.
+func printTop(w io.Writer, steps []TopStep) {
+	ms := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Millisecond))
+	}
+	fmt.Fprintf(w, "%10s  %10s  STEP\n", "DURATION", "SELF")
+	for _, s := range steps {
+		d, self := ms(s.DurationMs), ms(s.SelfTimeMs)
+		fmt.Fprintf(w, "%s  %s  [%d] %s\n", colorByDuration(fmt.Sprintf("%10s", formatDuration(d)), d), colorByDuration(fmt.Sprintf("%10s", formatDuration(self)), self), s.ID, s.Key)
+	}
+}
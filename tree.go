@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Node represents a step in the startup tree.
+type Node struct {
+	Event    Events
+	Parent   *Node
+	Children []*Node
+	Depth    int
+
+	// SelfTime is the step duration not covered by its children.
+	SelfTime time.Duration
+
+	// Index is the position of the event in the timeline; synthetic nodes,
+	// e.g. groups, have none.
+	Index int
+}
+
+// Tree represents the startup step hierarchy built from parentId.
+type Tree struct {
+	Roots []*Node
+
+	// Orphans are the ids of steps whose parent does not exist; they are
+	// promoted to roots.
+	Orphans []int
+
+	// Cycles are the ids of steps found on a cyclic parent chain; the chain is
+	// broken by promoting them to roots.
+	Cycles []int
+}
+
+// buildTree builds the step tree. All algorithms are iterative so deep
+// hierarchies can't exhaust the stack, and malformed parentId chains are
+// repaired instead of looping forever.
+func buildTree(t Timeline) *Tree {
+	tree := &Tree{}
+
+	// index nodes; on duplicated ids the first step wins.
+	nodes := make([]*Node, len(t.Events))
+	byID := make(map[int]*Node, len(t.Events))
+	for i, e := range t.Events {
+		nodes[i] = &Node{Event: e, Index: i}
+		if _, ok := byID[e.StartupStep.ID]; !ok {
+			byID[e.StartupStep.ID] = nodes[i]
+		}
+	}
+
+	// link parents.
+	for _, n := range nodes {
+		step := n.Event.StartupStep
+		if step.ParentID == nil {
+			continue
+		}
+		parent, ok := byID[*step.ParentID]
+		switch {
+		case !ok:
+			tree.Orphans = append(tree.Orphans, step.ID)
+		case parent == n:
+			tree.Cycles = append(tree.Cycles, step.ID)
+		default:
+			n.Parent = parent
+		}
+	}
+
+	// break cycles: any node whose parent chain revisits a node is reported,
+	// and the first revisited node is detached.
+	state := make(map[*Node]int, len(nodes)) // 1: on the current chain, 2: checked.
+	for _, n := range nodes {
+		var chain []*Node
+		for p := n; p != nil && state[p] == 0; p = p.Parent {
+			state[p] = 1
+			chain = append(chain, p)
+			if p.Parent != nil && state[p.Parent] == 1 {
+				tree.Cycles = append(tree.Cycles, p.Parent.Event.StartupStep.ID)
+				p.Parent.Parent = nil
+				break
+			}
+		}
+		for _, c := range chain {
+			state[c] = 2
+		}
+	}
+
+	// attach children in report order.
+	for _, n := range nodes {
+		if n.Parent == nil {
+			tree.Roots = append(tree.Roots, n)
+		} else {
+			n.Parent.Children = append(n.Parent.Children, n)
+		}
+	}
+
+	// depths and self times.
+	tree.Walk(func(n *Node) bool {
+		if n.Parent != nil {
+			n.Depth = n.Parent.Depth + 1
+		}
+		n.SelfTime = n.Event.Duration()
+		for _, c := range n.Children {
+			n.SelfTime -= c.Event.Duration()
+		}
+		if n.SelfTime < 0 {
+			n.SelfTime = 0
+		}
+		return true
+	})
+	return tree
+}
+
+// Walk visits the nodes depth-first in pre-order using an explicit stack.
+// Children of a node are skipped when fn returns false.
+func (t *Tree) Walk(fn func(n *Node) bool) {
+	stack := make([]*Node, 0, len(t.Roots))
+	for i := len(t.Roots) - 1; i >= 0; i-- {
+		stack = append(stack, t.Roots[i])
+	}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(n) {
+			continue
+		}
+		for i := len(n.Children) - 1; i >= 0; i-- {
+			stack = append(stack, n.Children[i])
+		}
+	}
+}
+
+// slowestNode returns the slowest of the nodes, or nil if there are none.
+func slowestNode(nodes []*Node) *Node {
+	var s *Node
+	for _, n := range nodes {
+		if s == nil || n.Event.Duration() > s.Event.Duration() {
+			s = n
+		}
+	}
+	return s
+}
+
+// Child sort orders.
+const (
+	OrderReport   = "report"
+	OrderStart    = "start"
+	OrderDuration = "duration"
+)
+
+// checkOrder returns an error if the child sort order is unknown.
+func checkOrder(order string) error {
+	switch order {
+	case "", OrderReport, OrderStart, OrderDuration:
+		return nil
+	}
+	return fmt.Errorf("unknown sort order %q, expected %s, %s or %s", order, OrderReport, OrderStart, OrderDuration)
+}
+
+// Sort orders the roots and the children of every node: chronologically by
+// start time, by duration with the heaviest first, or as in the report.
+func (t *Tree) Sort(order string) {
+	less := map[string]func(a, b *Node) bool{
+		OrderStart: func(a, b *Node) bool {
+			return a.Event.StartTime.Before(b.Event.StartTime)
+		},
+		OrderDuration: func(a, b *Node) bool {
+			return a.Event.Duration() > b.Event.Duration()
+		},
+	}[order]
+	if less == nil {
+		return
+	}
+	sortNodes := func(nodes []*Node) {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return less(nodes[i], nodes[j])
+		})
+	}
+	sortNodes(t.Roots)
+	t.Walk(func(n *Node) bool {
+		sortNodes(n.Children)
+		return true
+	})
+}
+
+// Reverse reverses the order of the roots and of the children of every node.
+func (t *Tree) Reverse() {
+	reverse := func(nodes []*Node) {
+		for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+			nodes[i], nodes[j] = nodes[j], nodes[i]
+		}
+	}
+	reverse(t.Roots)
+	t.Walk(func(n *Node) bool {
+		reverse(n.Children)
+		return true
+	})
+}
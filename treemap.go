@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log"
+	"net/http"
+)
+
+// TreemapNode represents a step in the treemap hierarchy. Value is the self
+// time, so treemap libraries summing the values of a subtree, e.g. d3's
+// hierarchy.sum, get its total time.
+type TreemapNode struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Value    float64        `json:"value"`
+	SelfMs   float64        `json:"selfMs"`
+	TotalMs  float64        `json:"totalMs"`
+	Children []*TreemapNode `json:"children,omitempty"`
+}
+
+// treemap returns the step tree, children sorted by order, under a synthetic
+// root node spanning the whole startup.
+func treemap(t Timeline, order string) *TreemapNode {
+	root := &TreemapNode{ID: -1, Name: "startup", TotalMs: millis(t.Duration())}
+	tree := buildTree(t)
+	tree.Sort(order)
+
+	// convert nodes; parents are visited before their children.
+	converted := make(map[*Node]*TreemapNode)
+	var covered float64
+	tree.Walk(func(n *Node) bool {
+		tn := &TreemapNode{
+			ID:      n.Event.StartupStep.ID,
+			Name:    n.Event.StartupStep.Key(),
+			Value:   millis(n.SelfTime),
+			SelfMs:  millis(n.SelfTime),
+			TotalMs: millis(n.Event.Duration()),
+		}
+		converted[n] = tn
+		parent := root
+		if n.Parent != nil {
+			parent = converted[n.Parent]
+		} else {
+			covered += tn.TotalMs
+		}
+		parent.Children = append(parent.Children, tn)
+		return true
+	})
+
+	// the root owns the startup time not covered by any step.
+	if covered < root.TotalMs {
+		root.SelfMs = root.TotalMs - covered
+		root.Value = root.SelfMs
+	}
+	return root
+}
+
+func handleTreemap(w http.ResponseWriter, r *http.Request) {
+	// get sort order.
+	order, err := orderParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// get report.
+	report, err := loadServedReport(r.Context())
+	if err != nil {
+		log.Printf("failed to unmarshal report: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// write treemap.
+	setWarningHeaders(w, report)
+	writeJSON(w, treemap(report.Timeline, order))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Trends represents how the startup time of the recorded reports evolves,
+// oldest report first.
+type Trends struct {
+	Reports []TrendReport `json:"reports"`
+	Total   Trend         `json:"total"`
+	Steps   []StepTrend   `json:"steps"`
+}
+
+// TrendReport represents a recorded report of the trends.
+type TrendReport struct {
+	HistoryID  int64     `json:"historyId"`
+	ReportID   string    `json:"reportId"`
+	RecordedAt time.Time `json:"recordedAt"`
+	Source     string    `json:"source"`
+}
+
+// Trend represents a duration across the recorded reports. The slope of its
+// least squares fit shows gradual regressions no single comparison does.
+type Trend struct {
+	ValuesMs         []float64 `json:"valuesMs"`
+	SlopeMsPerReport float64   `json:"slopeMsPerReport"`
+	ChangePercent    float64   `json:"changePercent"`
+}
+
+// StepTrend represents the durations of the steps of a name across the
+// recorded reports, 0 where a report has none.
+type StepTrend struct {
+	Name string `json:"name"`
+	Trend
+}
+
+// newTrend fits the values; ChangePercent is the fitted change from the first
+// to the last report.
+func newTrend(values []float64) Trend {
+	t := Trend{ValuesMs: values}
+	n := float64(len(values))
+	if len(values) < 2 {
+		return t
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, v := range values {
+		x := float64(i)
+		sumX, sumY, sumXY, sumXX = sumX+x, sumY+v, sumXY+x*v, sumXX+x*x
+	}
+	t.SlopeMsPerReport = (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	first := (sumY - t.SlopeMsPerReport*sumX) / n
+	if first > 0 {
+		t.ChangePercent = 100 * t.SlopeMsPerReport * (n - 1) / first
+	}
+	return t
+}
+
+// trendsQuery selects the recorded reports and steps of the trends.
+type trendsQuery struct {
+	limit  int
+	source string
+	names  []string
+	top    int
+}
+
+// parseTrendsQuery parses the ?limit, ?source, ?step and ?steps parameters.
+func parseTrendsQuery(r *http.Request) (trendsQuery, error) {
+	limit, err := historyLimit(r)
+	if err != nil {
+		return trendsQuery{}, err
+	}
+	q := trendsQuery{limit: limit, source: r.URL.Query().Get("source"), top: 10}
+	if v := r.URL.Query().Get("steps"); v != "" {
+		if q.top, err = strconv.Atoi(v); err != nil || q.top < 0 {
+			return trendsQuery{}, errors.New("invalid steps")
+		}
+	}
+	for _, name := range r.URL.Query()["step"] {
+		if name = strings.TrimSpace(name); name != "" {
+			q.names = append(q.names, name)
+		}
+	}
+	return q, nil
+}
+
+// historyReports loads the recorded reports of the source, or of all of them,
+// oldest first. progress, if not nil, is called after each report.
+func historyReports(ctx context.Context, limit int, source string, progress func(done, total int)) ([]HistoryEntry, []*StartupReport, error) {
+	entries, err := history.List(ctx, limit, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	var kept []HistoryEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		kept = append(kept, entries[i])
+	}
+	reports := make([]*StartupReport, len(kept))
+	for i, e := range kept {
+		if reports[i], err = history.Report(ctx, e.ID); err != nil {
+			return nil, nil, fmt.Errorf("failed to load recorded report %d: %w", e.ID, err)
+		}
+		if progress != nil {
+			progress(i+1, len(kept))
+		}
+	}
+	return kept, reports, nil
+}
+
+// trends computes the trends of the recorded reports of the query, keeping
+// the steps named or else the top slowest on average.
+func trends(ctx context.Context, q trendsQuery, progress func(done, total int)) (Trends, error) {
+	// load reports.
+	entries, reports, err := historyReports(ctx, q.limit, q.source, progress)
+	if err != nil {
+		return Trends{}, err
+	}
+	tr := Trends{Reports: []TrendReport{}, Steps: []StepTrend{}}
+	var totals []float64
+	var byName []map[string]time.Duration
+	for i, e := range entries {
+		tr.Reports = append(tr.Reports, TrendReport{HistoryID: e.ID, ReportID: e.ReportID, RecordedAt: e.RecordedAt, Source: e.Source})
+		totals = append(totals, e.StartupTimeMs)
+		byName = append(byName, stepDurationsByName(reports[i].Timeline))
+	}
+	tr.Total = newTrend(totals)
+
+	// steps, the slowest on average first.
+	names := q.names
+	if len(names) == 0 {
+		sums := make(map[string]time.Duration)
+		for _, steps := range byName {
+			for name, d := range steps {
+				sums[name] += d
+			}
+		}
+		names = sortedKeys(sums)
+		sort.SliceStable(names, func(i, j int) bool {
+			return sums[names[i]] > sums[names[j]]
+		})
+		if len(names) > q.top {
+			names = names[:q.top]
+		}
+	}
+	for _, name := range names {
+		values := make([]float64, len(byName))
+		for i, steps := range byName {
+			values[i] = millis(steps[name])
+		}
+		tr.Steps = append(tr.Steps, StepTrend{Name: name, Trend: newTrend(values)})
+	}
+	return tr, nil
+}
+
+func handleTrends(w http.ResponseWriter, r *http.Request) {
+	// get query.
+	q, err := parseTrendsQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// compute trends.
+	tr, err := trends(r.Context(), q, nil)
+	if err != nil {
+		log.Printf("failed to compute trends: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, tr)
+}
+
+// trendPoints returns the svg polyline points charting the startup time of
+// the entries, newest first, within a width by height box.
+func trendPoints(entries []HistoryEntry, width, height float64) string {
+	if len(entries) < 2 {
+		return ""
+	}
+	var max float64
+	for _, e := range entries {
+		if e.StartupTimeMs > max {
+			max = e.StartupTimeMs
+		}
+	}
+	if max == 0 {
+		return ""
+	}
+	points := make([]string, len(entries))
+	for i, e := range entries {
+		x := width * float64(len(entries)-1-i) / float64(len(entries)-1)
+		y := height - height*e.StartupTimeMs/max
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// maxEvents caps the number of events loaded per report, 0 means no cap.
+var maxEvents int
+
+// cappedEvent is an event competing for a place under the cap.
+type cappedEvent struct {
+	Events
+	index int
+}
+
+// eventHeap is a min-heap of events, top-level events ranking above children
+// and slower events above faster ones.
+type eventHeap []cappedEvent
+
+func (h eventHeap) Len() int      { return len(h) }
+func (h eventHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h eventHeap) Less(i, j int) bool {
+	if h[i].IsRoot() != h[j].IsRoot() {
+		return !h[i].IsRoot()
+	}
+	return h[i].Duration() < h[j].Duration()
+}
+func (h *eventHeap) Push(x interface{}) { *h = append(*h, x.(cappedEvent)) }
+func (h *eventHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// decodeCapped decodes a report keeping at most max events: the top-level ones
+// first, then the slowest. Events are streamed from the decoder so the dropped
+// ones are never held in memory together; decoding stops between events once
+// ctx is done. The report is otherwise decoded as json.Unmarshal does.
+func decodeCapped(ctx context.Context, reportContent []byte, max int) (*StartupReport, error) {
+	dec := json.NewDecoder(bytes.NewReader(reportContent))
+	if strictParsing {
+		dec.DisallowUnknownFields()
+	}
+
+	var report StartupReport
+	var kept eventHeap
+	total := 0
+	err := decodeObject(dec, func(key string) error {
+		switch fieldName(key, "springBootVersion", "timeline") {
+		case "springBootVersion":
+			return dec.Decode(&report.SpringBootVersion)
+		case "timeline":
+			return decodeObject(dec, func(key string) error {
+				switch fieldName(key, "startTime", "events") {
+				case "startTime":
+					return dec.Decode(&report.Timeline.StartTime)
+				case "events":
+					return decodeArray(dec, func() error {
+						if err := ctx.Err(); err != nil {
+							return err
+						}
+						var e Events
+						if err := dec.Decode(&e); err != nil {
+							return err
+						}
+						heap.Push(&kept, cappedEvent{Events: e, index: total})
+						if kept.Len() > max {
+							heap.Pop(&kept)
+						}
+						total++
+						return nil
+					})
+				}
+				return skipValue(dec, "timeline."+key)
+			})
+		}
+		return skipValue(dec, key)
+	})
+	if err == nil {
+		err = expectEnd(dec)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// restore the report order.
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].index < kept[j].index
+	})
+	for _, e := range kept {
+		report.Timeline.Events = append(report.Timeline.Events, e.Events)
+	}
+	if total > len(kept) {
+		report.Truncation = &Truncation{Total: total, Kept: len(kept)}
+	}
+	return &report, nil
+}
+
+// capReport keeps at most max events of an already decoded report, ranked as
+// decodeCapped does. A report truncated before keeps its original total.
+func capReport(report *StartupReport, max int) {
+	events := report.Timeline.Events
+	if len(events) <= max {
+		return
+	}
+	var kept eventHeap
+	for i, e := range events {
+		heap.Push(&kept, cappedEvent{Events: e, index: i})
+		if kept.Len() > max {
+			heap.Pop(&kept)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		return kept[i].index < kept[j].index
+	})
+	report.Timeline.Events = make([]Events, len(kept))
+	for i, e := range kept {
+		report.Timeline.Events[i] = e.Events
+	}
+	total := len(events)
+	if report.Truncation != nil {
+		total = report.Truncation.Total
+	}
+	report.Truncation = &Truncation{Total: total, Kept: len(kept)}
+}
+
+// fieldName returns the name of the field matching the key, ignoring case as
+// json.Unmarshal does, or the key itself when none matches.
+func fieldName(key string, names ...string) string {
+	for _, name := range names {
+		if key == name {
+			return name
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(key, name) {
+			return name
+		}
+	}
+	return key
+}
+
+// decodeObject calls field for every key of the json object read from dec; a
+// null object has no keys.
+func decodeObject(dec *json.Decoder, field func(key string) error) error {
+	if null, err := expectDelim(dec, '{'); err != nil || null {
+		return err
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(t.(string)); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// decodeArray calls elem for every element of the json array read from dec; a
+// null array has no elements.
+func decodeArray(dec *json.Decoder, elem func() error) error {
+	if null, err := expectDelim(dec, '['); err != nil || null {
+		return err
+	}
+	for dec.More() {
+		if err := elem(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// expectDelim reads the opening delim of an object or array, reporting
+// whether a null was read instead.
+func expectDelim(dec *json.Decoder, delim json.Delim) (bool, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if t == nil {
+		return true, nil
+	}
+	if t != delim {
+		return false, fmt.Errorf("expected %s, got %v", delim, t)
+	}
+	return false, nil
+}
+
+// expectEnd returns an error unless the report is followed by nothing but
+// whitespace.
+func expectEnd(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after report")
+	}
+	return nil
+}
+
+// skipValue skips an unknown field value, which is an error in strict mode.
+func skipValue(dec *json.Decoder, key string) error {
+	if strictParsing {
+		return fmt.Errorf("json: unknown field %q", key)
+	}
+	var v json.RawMessage
+	return dec.Decode(&v)
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// tuiOrders are the child sort orders the tui cycles through.
+var tuiOrders = []string{OrderDuration, OrderStart, OrderReport}
+
+// runTUI implements the tui command: it browses the report tree in the
+// terminal, for boxes with no browser at hand.
+func runTUI(args []string) {
+	// load configs.
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	path := fs.String("report", "", "spring actuator startup report, file, url or - for stdin. required!")
+	order := fs.String("order", OrderDuration, "initial child sort order: duration, start or report.")
+	registerParseFlags(fs)
+	registerFetchFlags(fs)
+	registerColorFlags(fs)
+	fs.Parse(args)
+
+	// check configs.
+	if *path == "" {
+		log.Fatal("startup report is required!")
+	}
+	if *path == "-" {
+		log.Fatal("the tui reads keys from stdin, the report can't be read from it!")
+	}
+	if err := checkOrder(*order); err != nil {
+		log.Fatal(err)
+	}
+
+	// get report.
+	report, err := loadReport(context.Background(), *path)
+	if err != nil {
+		log.Fatalf("failed to load report: %s", err)
+	}
+
+	// browse report.
+	if err := browse(report, *order); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// browse runs the tui on the terminal until the user quits.
+func browse(report *StartupReport, order string) error {
+	// set up terminal.
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return errors.New("goat tui requires a terminal!")
+	}
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer restore()
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	// read keys and resizes.
+	keys := make(chan string)
+	go func() {
+		r := bufio.NewReader(os.Stdin)
+		for {
+			key, err := readKey(r)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- key
+		}
+	}()
+	resizes := make(chan os.Signal, 1)
+	notifyResize(resizes)
+
+	// render until quit.
+	ui := newTUI(report, order)
+	for {
+		ui.width, ui.height = terminalSize(int(os.Stdout.Fd()))
+		var buf strings.Builder
+		ui.render(&buf)
+		io.WriteString(os.Stdout, buf.String())
+		select {
+		case key, ok := <-keys:
+			if !ok || !ui.handle(key) {
+				return nil
+			}
+		case <-resizes:
+		}
+	}
+}
+
+// isTerminal reports whether the file is a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readKey reads a key press, naming the arrow and paging keys, e.g. up.
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl+c", nil
+	case 0x1b:
+		// escape sequences arrive at once; a lone escape does not.
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		seq := make([]byte, 0, 4)
+		for r.Buffered() > 0 && len(seq) < 4 {
+			b, _ := r.ReadByte()
+			seq = append(seq, b)
+			if len(seq) > 1 && (b >= 'A' && b <= 'Z' || b == '~') {
+				break
+			}
+		}
+		switch string(seq) {
+		case "[A", "OA":
+			return "up", nil
+		case "[B", "OB":
+			return "down", nil
+		case "[C", "OC":
+			return "right", nil
+		case "[D", "OD":
+			return "left", nil
+		case "[5~":
+			return "pgup", nil
+		case "[6~":
+			return "pgdn", nil
+		case "[H", "OH", "[1~":
+			return "home", nil
+		case "[F", "OF", "[4~":
+			return "end", nil
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
+
+// tui represents the state of the terminal ui: the tree, sorted by order, and
+// the rows of its expanded nodes.
+type tui struct {
+	report   *StartupReport
+	order    string
+	tree     *Tree
+	expanded map[int]bool // by node index.
+	rows     []*Node
+	cursor   int
+	offset   int
+	width    int
+	height   int
+}
+
+// newTUI returns the tui of the report with its roots expanded.
+func newTUI(report *StartupReport, order string) *tui {
+	ui := &tui{report: report, expanded: make(map[int]bool), width: 80, height: 24}
+	ui.sort(order)
+	for _, n := range ui.tree.Roots {
+		ui.expanded[n.Index] = true
+	}
+	ui.flatten()
+	return ui
+}
+
+// sort rebuilds the tree in the order, keeping the cursor on its step.
+func (ui *tui) sort(order string) {
+	selected := -1
+	if n := ui.selected(); n != nil {
+		selected = n.Index
+	}
+	ui.order = order
+	ui.tree = buildTree(ui.report.Timeline)
+	ui.tree.Sort(order)
+	ui.flatten()
+	for i, n := range ui.rows {
+		if n.Index == selected {
+			ui.cursor = i
+		}
+	}
+}
+
+// flatten lists the visible nodes, those whose ancestors are all expanded.
+func (ui *tui) flatten() {
+	ui.rows = ui.rows[:0]
+	if ui.tree != nil {
+		ui.tree.Walk(func(n *Node) bool {
+			ui.rows = append(ui.rows, n)
+			return ui.expanded[n.Index]
+		})
+	}
+	ui.cursor = max(0, min(ui.cursor, len(ui.rows)-1))
+}
+
+// selected returns the node under the cursor, if any.
+func (ui *tui) selected() *Node {
+	if ui.cursor < len(ui.rows) {
+		return ui.rows[ui.cursor]
+	}
+	return nil
+}
+
+// pageSize returns the number of rows fitting the screen, below the header
+// and above the help line.
+func (ui *tui) pageSize() int {
+	return max(1, ui.height-4)
+}
+
+// handle applies the key and reports whether the tui keeps running.
+func (ui *tui) handle(key string) bool {
+	n := ui.selected()
+	switch key {
+	case "q", "esc", "ctrl+c":
+		return false
+	case "up", "k":
+		ui.cursor--
+	case "down", "j":
+		ui.cursor++
+	case "pgup":
+		ui.cursor -= ui.pageSize()
+	case "pgdn", " ":
+		ui.cursor += ui.pageSize()
+	case "home", "g":
+		ui.cursor = 0
+	case "end", "G":
+		ui.cursor = len(ui.rows) - 1
+	case "enter":
+		if n != nil && len(n.Children) > 0 {
+			ui.expanded[n.Index] = !ui.expanded[n.Index]
+		}
+	case "right", "l":
+		// expand, or step into the expanded node.
+		if n != nil && len(n.Children) > 0 {
+			if ui.expanded[n.Index] {
+				ui.cursor++
+			}
+			ui.expanded[n.Index] = true
+		}
+	case "left", "h":
+		// collapse, or step out to the parent.
+		if n == nil {
+			break
+		}
+		if ui.expanded[n.Index] && len(n.Children) > 0 {
+			ui.expanded[n.Index] = false
+			break
+		}
+		for i := ui.cursor - 1; i >= 0; i-- {
+			if ui.rows[i] == n.Parent {
+				ui.cursor = i
+				break
+			}
+		}
+	case "e":
+		ui.tree.Walk(func(n *Node) bool {
+			ui.expanded[n.Index] = true
+			return true
+		})
+	case "c":
+		// keep the cursor on the root of its step.
+		for n != nil && n.Parent != nil {
+			n = n.Parent
+		}
+		ui.expanded = make(map[int]bool)
+		ui.flatten()
+		for i, r := range ui.rows {
+			if r == n {
+				ui.cursor = i
+			}
+		}
+	case "s":
+		for i, o := range tuiOrders {
+			if o == ui.order || i == len(tuiOrders)-1 {
+				ui.sort(tuiOrders[(i+1)%len(tuiOrders)])
+				break
+			}
+		}
+	}
+	ui.flatten()
+	return true
+}
+
+// render draws the screen: the startup time, the visible rows around the
+// cursor and the key help.
+func (ui *tui) render(w io.Writer) {
+	// scroll to the cursor.
+	page := ui.pageSize()
+	if ui.cursor < ui.offset {
+		ui.offset = ui.cursor
+	}
+	if ui.cursor >= ui.offset+page {
+		ui.offset = ui.cursor - page + 1
+	}
+
+	// header.
+	fmt.Fprint(w, "\x1b[H\x1b[2J")
+	t := ui.report.Timeline
+	ui.line(w, fmt.Sprintf("STARTUP TIME: %s  STEPS: %d  ORDER: %s", formatDuration(t.Duration()), len(t.Events), ui.order), "\x1b[1m")
+	ui.line(w, "", "")
+	ui.line(w, fmt.Sprintf("%10s  %10s  STEP", "DURATION", "SELF"), "\x1b[1m")
+
+	// rows.
+	for i := ui.offset; i < len(ui.rows) && i < ui.offset+page; i++ {
+		n := ui.rows[i]
+		marker := "  "
+		if len(n.Children) > 0 {
+			marker = "▸ "
+			if ui.expanded[n.Index] {
+				marker = "▾ "
+			}
+		}
+		d := n.Event.Duration()
+		text := fmt.Sprintf("%10s  %10s  %s%s%s", formatDuration(d), formatDuration(n.SelfTime), strings.Repeat("  ", n.Depth), marker, n.Event.StartupStep.Key())
+		style := ""
+		switch {
+		case i == ui.cursor:
+			style = "\x1b[7m"
+		case useColor():
+			style = levelColors[levelBasedOnDuration(d)]
+		}
+		ui.line(w, text, style)
+	}
+
+	// help.
+	fmt.Fprintf(w, "\x1b[%d;1H", ui.height)
+	fmt.Fprint(w, truncateText("↑↓ move  → expand  ← collapse  enter toggle  e/c expand/collapse all  s sort  q quit", ui.width))
+}
+
+// line writes the text cut to the screen width, in the ansi style if any.
+func (ui *tui) line(w io.Writer, text, style string) {
+	text = truncateText(text, ui.width)
+	if style != "" {
+		text = style + text + "\x1b[0m"
+	}
+	fmt.Fprint(w, text+"\r\n")
+}
+
+// truncateText cuts the text to width runes.
+func truncateText(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:max(0, width)])
+}
@@ -0,0 +1,9 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// termios ioctl requests.
+const (
+	ioctlGetTermios = unix.TIOCGETA
+	ioctlSetTermios = unix.TIOCSETA
+)
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// makeRaw fails: the tui is only supported on linux and macos terminals.
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("goat tui is only supported on linux and macos")
+}
+
+// terminalSize returns the default 80x24 size.
+func terminalSize(fd int) (int, int) {
+	return 80, 24
+}
+
+// notifyResize does nothing.
+func notifyResize(c chan<- os.Signal) {}
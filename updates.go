@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// updatesKeepAlive is the interval of the comments keeping idle update
+// streams open through proxies.
+const updatesKeepAlive = 30 * time.Second
+
+// ReportUpdate represents a new report loaded by a served source, pushed to
+// the pages showing it.
+type ReportUpdate struct {
+	ReportID      string    `json:"reportId"`
+	StartupTimeMs float64   `json:"startupTimeMs"`
+	LoadedAt      time.Time `json:"loadedAt"`
+}
+
+// reportUpdates fans out the new reports of every served source to the
+// subscribed update streams.
+var reportUpdates = &updateHub{
+	subscribers: make(map[*servedSource]map[chan ReportUpdate]bool),
+	done:        make(chan struct{}),
+}
+
+// updateHub holds the update subscribers of each served source. done is
+// closed on shutdown, ending the streams.
+type updateHub struct {
+	sync.Mutex
+	subscribers map[*servedSource]map[chan ReportUpdate]bool
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// close ends the update streams.
+func (h *updateHub) close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// subscribe returns a channel receiving the updates of the source, and a
+// function unsubscribing it.
+func (h *updateHub) subscribe(s *servedSource) (<-chan ReportUpdate, func()) {
+	h.Lock()
+	defer h.Unlock()
+	c := make(chan ReportUpdate, 1)
+	if h.subscribers[s] == nil {
+		h.subscribers[s] = make(map[chan ReportUpdate]bool)
+	}
+	h.subscribers[s][c] = true
+	return c, func() {
+		h.Lock()
+		defer h.Unlock()
+		delete(h.subscribers[s], c)
+	}
+}
+
+// publish sends the update to the subscribers of the source; subscribers
+// still busy with the previous update miss it, they reload the latest report
+// anyway.
+func (h *updateHub) publish(s *servedSource, update ReportUpdate) {
+	h.Lock()
+	defer h.Unlock()
+	for c := range h.subscribers[s] {
+		select {
+		case c <- update:
+		default:
+		}
+	}
+}
+
+// handleUpdates streams the reports newly loaded by the served source as
+// server-sent events, so pages reload on restarts of the app.
+func handleUpdates(w http.ResponseWriter, r *http.Request) {
+	// streams outlast the server write timeout.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("failed to clear updates write deadline: %s", err)
+	}
+
+	// subscribe.
+	updates, unsubscribe := reportUpdates.subscribe(servedSourceOf(r.Context()))
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Printf("failed to flush updates: %s", err)
+		return
+	}
+
+	// push updates until the client leaves.
+	keepAlive := time.NewTicker(updatesKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-reportUpdates.done:
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case update := <-updates:
+			var data []byte
+			data, err = json.Marshal(update)
+			if err == nil {
+				_, err = fmt.Fprintf(w, "event: report\ndata: %s\n\n", data)
+			}
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
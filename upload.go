@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+)
+
+func handleUploadForm(w http.ResponseWriter, r *http.Request) {
+	writeUploadPage(w, http.StatusOK, "")
+}
+
+func handleUpload(w http.ResponseWriter, r *http.Request) {
+	// get uploaded report; like pushed reports, it may be gzipped or zipped.
+	if maxReportSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxReportSize+1<<20)
+	}
+	file, _, err := r.FormFile("report")
+	if err != nil {
+		writeUploadPage(w, bodyErrorStatus(err, http.StatusBadRequest), "no report file uploaded: "+err.Error())
+		return
+	}
+	defer file.Close()
+	content, err := readReportContent(file)
+	if err != nil {
+		writeUploadPage(w, bodyErrorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+	report, err := decodeReport(r.Context(), content)
+	if err != nil {
+		writeUploadPage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := recordHistory(r.Context(), report, "upload"); err != nil {
+		log.Printf("failed to record report: %s", err)
+	}
+
+	// render the report page; it has no server links, they would show the
+	// served report.
+	page, err := renderReportPage(r.Context(), &ServedReport{StartupReport: report}, pageOptions{Sort: defaultPageOptions.Sort, Standalone: true})
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(page)
+}
+
+// writeUploadPage renders the upload form, with the error of the previous
+// upload if any.
+func writeUploadPage(w http.ResponseWriter, status int, uploadErr string) {
+	page, err := renderUploadPage(uploadErr)
+	if err != nil {
+		log.Printf("failed to render template: %s", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	w.Write(page)
+}
+
+// renderUploadPage renders the upload form.
+func renderUploadPage(uploadErr string) ([]byte, error) {
+	// load template.
+	tpl, err := template.ParseFS(files, "web/upload.html")
+	if err != nil {
+		return nil, err
+	}
+
+	// render template.
+	var buf bytes.Buffer
+	if err := tpl.ExecuteTemplate(&buf, "upload.html", struct{ Error string }{uploadErr}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportViewVersion is the version of ReportView. It is bumped whenever a
+// field is removed, renamed or changes type; fields may be added without it.
+// Templates declare the version they are written for in a "view-version"
+// template, checked before rendering.
+const reportViewVersion = 3
+
+// ReportView is the data the report page template is rendered with. Templates
+// depend on it and its View types alone, not on the report types, so internal
+// changes don't break them.
+type ReportView struct {
+	// Version is the ReportView version, see reportViewVersion.
+	Version int
+
+	// SpringBootVersion and StartupTime are the report headline figures.
+	SpringBootVersion string
+	StartupTime       time.Duration
+
+	// Milestones are the times to context refresh, started and ready.
+	Milestones ViewMilestones
+
+	// Steps are the steps to render, in page order.
+	Steps []ViewStep
+
+	// Failure is set when the startup failed.
+	Failure *ViewFailure
+
+	// Pagination is set when a page of the steps is rendered.
+	Pagination *ViewPagination
+
+	// Filter describes the filter narrowing the steps down, if any.
+	Filter string
+
+	// Diagnostics are the timeline data-quality problems.
+	Diagnostics []ViewDiagnostic
+
+	// Summary is the summarizer text, if configured.
+	Summary string
+
+	// Stale is set when an older copy of the report is served; Truncation
+	// when the report lost events to the event limit.
+	Stale      *ViewStale
+	Truncation *ViewTruncation
+
+	// Live is set when the report is streamed, and Compare when a comparison
+	// report is served at /compare.
+	Live    bool
+	Compare bool
+
+	// History is set when the reports are recorded at /history.
+	History bool
+
+	// RefreshSeconds is the interval the page reloads itself at; 0 disables
+	// reloading. Updates is set when the page also reloads on the new
+	// reports pushed at api/updates.
+	RefreshSeconds int
+	Updates        bool
+
+	// ShareToken is the signed view token of the page options, when they are
+	// not the defaults; ?view=<token> renders the same view.
+	ShareToken string
+
+	// Standalone is set on exported pages, which inline Style instead of
+	// linking the stylesheet and have no server to link to.
+	Standalone bool
+	Style      template.CSS
+}
+
+// checkViewVersion returns an error unless the template declares, in its
+// "view-version" template, the ReportView version goat renders.
+func checkViewVersion(tpl *template.Template) error {
+	var buf bytes.Buffer
+	if tpl.Lookup("view-version") == nil {
+		return errors.New("report template doesn't declare its view-version")
+	}
+	if err := tpl.ExecuteTemplate(&buf, "view-version", nil); err != nil {
+		return err
+	}
+	if v := strings.TrimSpace(buf.String()); v != strconv.Itoa(reportViewVersion) {
+		return fmt.Errorf("report template is written for view version %s, goat renders version %d", v, reportViewVersion)
+	}
+	return nil
+}
+
+// ViewMilestones are the times to context refresh, started and ready; 0 when
+// not reached.
+type ViewMilestones struct {
+	ContextRefreshed time.Duration
+	Started          time.Duration
+	Ready            time.Duration
+}
+
+// ViewStep represents a step of the report page.
+type ViewStep struct {
+	ID       int
+	Name     string
+	Tags     []ViewTag
+	Duration time.Duration
+	Depth    int
+
+	// SelfTime is the step duration not covered by its children.
+	SelfTime time.Duration
+
+	// Folded is the number of steps aggregated into this one, which is then a
+	// synthetic step; 0 for report steps.
+	Folded int
+
+	// Group is set when the step is a synthetic step aggregating siblings
+	// sharing a name.
+	Group *ViewGroup
+
+	// Hidden is the number of descendants not rendered because of the depth
+	// limit.
+	Hidden int
+
+	// Context is set when the step doesn't match the filter, and is rendered
+	// for the matching steps under it.
+	Context bool
+
+	// Open is set when the step children are rendered after it, nested in its
+	// collapsible subtree; Close is the number of subtrees ending after it.
+	Open  bool
+	Close int
+}
+
+// ViewTag represents a step tag.
+type ViewTag struct {
+	Key   string
+	Value string
+}
+
+// ViewGroup represents siblings sharing a name, aggregated into one step.
+// ParentID is their parent step, when HasParent is set.
+type ViewGroup struct {
+	Name      string
+	ParentID  int
+	HasParent bool
+	Count     int
+	Max       time.Duration
+}
+
+// ViewFailure represents a startup failure: the failing step, its ancestors
+// from the top-level step down to its parent, and its exception tags.
+type ViewFailure struct {
+	Step       ViewStepRef
+	Chain      []ViewStepRef
+	Exceptions []ViewTag
+
+	// Unfinished is set when the failing step never ended.
+	Unfinished bool
+}
+
+// ViewStepRef identifies a step by id and key, the name and bean.
+type ViewStepRef struct {
+	ID  int
+	Key string
+}
+
+// ViewDiagnostic represents a timeline data-quality problem.
+type ViewDiagnostic struct {
+	Kind    string
+	Message string
+	StepIDs []int
+}
+
+// ViewStale tells when the served copy of a report that could not be
+// reloaded was loaded, and why reloading failed.
+type ViewStale struct {
+	LoadedAt time.Time
+	Error    string
+}
+
+// ViewTruncation tells how many of the report events were kept.
+type ViewTruncation struct {
+	Kept  int
+	Total int
+}
+
+// ViewPagination represents the page of steps rendered, with the view tokens
+// of the previous and next pages. Ranked is set when the steps are ranked by
+// duration across the tree rather than listed in tree order.
+type ViewPagination struct {
+	Page   int
+	Pages  int
+	Size   int
+	Total  int
+	Prev   string
+	Next   string
+	Ranked bool
+}
+
+// newReportView returns the view of the served report.
+func newReportView(report *ServedReport, opts pageOptions) ReportView {
+	m := report.Timeline.Milestones()
+	view := ReportView{
+		Version:           reportViewVersion,
+		SpringBootVersion: report.SpringBootVersion,
+		StartupTime:       report.Timeline.Duration(),
+		Milestones:        ViewMilestones{ContextRefreshed: m.ContextRefreshed, Started: m.Started, Ready: m.Ready},
+		Failure:           viewFailure(detectFailure(report.Timeline)),
+		Live:              live != nil,
+		Compare:           compareSource != "" && !opts.Standalone,
+		History:           history != nil && !opts.Standalone,
+		Standalone:        opts.Standalone,
+	}
+	for _, issue := range checkTimelineIntegrity(report.Timeline) {
+		view.Diagnostics = append(view.Diagnostics, ViewDiagnostic{Kind: issue.Kind, Message: issue.Message, StepIDs: issue.StepIDs})
+	}
+	if s := report.Stale; s != nil {
+		view.Stale = &ViewStale{LoadedAt: s.LoadedAt, Error: s.Err.Error()}
+	}
+	if t := report.Truncation; t != nil {
+		view.Truncation = &ViewTruncation{Kept: t.Kept, Total: t.Total}
+	}
+	if opts.Filter.active() {
+		view.Filter = opts.Filter.String()
+	}
+	steps := pageSteps(report.Timeline, opts)
+	if opts.Page > 0 {
+		var p *Pagination
+		steps, p = paginateSteps(steps, opts)
+		view.Pagination = &ViewPagination{Page: p.Page, Pages: p.Pages, Size: p.Size, Total: p.Total, Prev: p.Prev, Next: p.Next, Ranked: p.Ranked}
+	}
+	view.Steps = viewSteps(steps)
+	if !opts.Standalone {
+		view.RefreshSeconds = refreshSeconds()
+		view.Updates = live == nil
+	}
+	if !opts.Standalone && opts != defaultPageOptions {
+		view.ShareToken = encodeViewToken(opts)
+	}
+	return view
+}
+
+// viewSteps copies the page steps into view steps.
+func viewSteps(steps []PageStep) []ViewStep {
+	view := make([]ViewStep, len(steps))
+	for i, s := range steps {
+		view[i] = ViewStep{
+			ID:       s.StartupStep.ID,
+			Name:     s.StartupStep.Name,
+			Tags:     viewTags(s.StartupStep.Tags),
+			Duration: s.Duration(),
+			Depth:    s.Depth,
+			SelfTime: s.SelfTime,
+			Folded:   s.Folded,
+			Hidden:   s.Hidden,
+			Context:  s.Context,
+			Open:     s.Open,
+			Close:    s.Close,
+		}
+		if g := s.Group; g != nil {
+			view[i].Group = &ViewGroup{Name: g.Name, Count: g.Count, Max: g.Max}
+			if g.ParentID != nil {
+				view[i].Group.ParentID, view[i].Group.HasParent = *g.ParentID, true
+			}
+		}
+	}
+	return view
+}
+
+// viewFailure copies the failure into a view failure; nil stays nil.
+func viewFailure(f *Failure) *ViewFailure {
+	if f == nil {
+		return nil
+	}
+	ref := func(e Events) ViewStepRef {
+		return ViewStepRef{ID: e.StartupStep.ID, Key: e.StartupStep.Key()}
+	}
+	view := &ViewFailure{Step: ref(f.Step), Exceptions: viewTags(f.Exceptions), Unfinished: f.Unfinished}
+	for _, e := range f.Chain {
+		view.Chain = append(view.Chain, ref(e))
+	}
+	return view
+}
+
+// viewTags copies the tags into view tags.
+func viewTags(tags []Tags) []ViewTag {
+	var view []ViewTag
+	for _, t := range tags {
+		view = append(view, ViewTag{Key: t.Key, Value: t.Value})
+	}
+	return view
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// viewSecret signs the view tokens. It is read from GOAT_VIEW_SECRET so links
+// survive restarts and work across instances; otherwise it is random and
+// tokens expire with the process.
+var viewSecret = func() []byte {
+	if secret := os.Getenv("GOAT_VIEW_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}()
+
+// viewState is the page state carried by a view token, with short keys to
+// keep urls compact.
+type viewState struct {
+	CollapseBelow time.Duration `json:"c,omitempty"`
+	GroupAbove    int           `json:"g,omitempty"`
+	MaxDepth      int           `json:"d,omitempty"`
+	Sort          string        `json:"s,omitempty"`
+	Name          string        `json:"n,omitempty"`
+	MinDuration   time.Duration `json:"m,omitempty"`
+	Tag           string        `json:"t,omitempty"`
+	Order         string        `json:"o,omitempty"`
+	Page          int           `json:"p,omitempty"`
+	Size          int           `json:"z,omitempty"`
+}
+
+// encodeViewToken encodes the page options into a signed url token.
+func encodeViewToken(opts pageOptions) string {
+	payload, _ := json.Marshal(viewState{opts.CollapseBelow, opts.GroupAbove, opts.MaxDepth, opts.Sort, opts.Filter.Name, opts.Filter.MinDuration, opts.Filter.Tag, opts.Order, opts.Page, opts.Size})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signView(payload))
+}
+
+// decodeViewToken decodes the page options of a token made by
+// encodeViewToken, failing on tampered tokens.
+func decodeViewToken(token string) (pageOptions, error) {
+	invalid := errors.New("invalid view token")
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return pageOptions{}, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageOptions{}, invalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signView(payload)) {
+		return pageOptions{}, invalid
+	}
+	var s viewState
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return pageOptions{}, invalid
+	}
+	if s.Sort == "" {
+		s.Sort = defaultPageOptions.Sort
+	}
+	filter := stepFilter{Name: s.Name, MinDuration: s.MinDuration, Tag: s.Tag}
+	return pageOptions{CollapseBelow: s.CollapseBelow, GroupAbove: s.GroupAbove, MaxDepth: s.MaxDepth, Sort: s.Sort, Order: s.Order, Page: s.Page, Size: s.Size, Filter: filter}, checkOrder(s.Sort)
+}
+
+// signView returns the truncated hmac of the token payload.
+func signView(payload []byte) []byte {
+	mac := hmac.New(sha256.New, viewSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)[:16]
+}
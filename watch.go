@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watcher waits for writes to settle before
+// reloading the report.
+const watchDebounce = 100 * time.Millisecond
+
+// watchServedReport reloads the served report as soon as its file changes.
+// Directories are watched rather than files, so rewrites through a rename and
+// rotated symlinks are noticed too.
+func watchServedReport() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watchReportDirs(watcher); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		reload := time.NewTimer(watchDebounce)
+		reload.Stop()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op != fsnotify.Chmod {
+					reload.Reset(watchDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("failed to watch report: %s", err)
+			case <-reload.C:
+				if snapshotPages {
+					refreshSnapshot(context.Background())
+				} else {
+					loadServedReport(context.Background())
+				}
+
+				// a rotated symlink may point to another directory.
+				if err := watchReportDirs(watcher); err != nil {
+					log.Printf("failed to watch report: %s", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// watchReportDirs watches the directories of the report path and of the file
+// it resolves to.
+func watchReportDirs(watcher *fsnotify.Watcher) error {
+	dirs := []string{reportPath}
+	if info, err := os.Stat(reportPath); err != nil || !info.IsDir() {
+		dirs[0] = filepath.Dir(reportPath)
+	}
+	if target, err := resolveReportFile(reportPath); err == nil {
+		dirs = append(dirs, filepath.Dir(target))
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
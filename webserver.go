@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// webServers are the lower case names identifying embedded web server beans.
+var webServers = []string{"tomcat", "jetty", "netty", "undertow", "webserver"}
+
+// isWebServerStep reports whether the step is about an embedded web server
+// bean: its factory, customizers or start/stop lifecycle.
+func isWebServerStep(s StartupStep) bool {
+	class := strings.ToLower(s.Tag("beanType") + " " + s.Tag("beanName"))
+	for _, server := range webServers {
+		if strings.Contains(class, server) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkWebServer(t Timeline) []Finding {
+	// split bean time between the web server and the application; nested
+	// beans belong to their outermost step.
+	var serverIDs []int
+	var serverTime, beanTime time.Duration
+	var slow []*Node
+	buildTree(t).Walk(func(n *Node) bool {
+		step := n.Event.StartupStep
+		switch {
+		case isWebServerStep(step):
+			serverIDs = append(serverIDs, step.ID)
+			serverTime += n.Event.Duration()
+			if n.SelfTime >= 500*time.Millisecond {
+				slow = append(slow, n)
+			}
+			return false
+		case step.Name == "spring.beans.instantiate":
+			beanTime += n.Event.Duration()
+			return false
+		}
+		return true
+	})
+	if len(serverIDs) == 0 {
+		return nil
+	}
+
+	findings := []Finding{{
+		Severity:   SeverityInfo,
+		Message:    fmt.Sprintf("the embedded web server took %s to initialize, apart from %s of application beans.", formatDuration(serverTime), formatDuration(beanTime)),
+		StepIDs:    serverIDs,
+		DurationMs: millis(serverTime),
+	}}
+
+	// unusual delays.
+	for _, n := range slow {
+		findings = append(findings, Finding{
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("%s took %s; creating the server and binding its connectors is usually fast, check the DNS resolution of server.address and the loading of server.ssl keystores.", n.Event.StartupStep.Key(), formatDuration(n.SelfTime)),
+			StepIDs:    []int{n.Event.StartupStep.ID},
+			DurationMs: millis(n.SelfTime),
+		})
+	}
+	return findings
+}